@@ -6,29 +6,70 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"runtime"
+	"strings"
 
 	"github.com/teleivo/surefire-reports-to-csv/surefire"
 )
 
 func main() {
-	if err := run(os.Args, os.Stdout); err != nil {
+	if err := run(os.Args, os.Stdout, os.Stderr); err != nil {
 		fmt.Fprint(os.Stderr, err, "\n")
 		os.Exit(1)
 	}
 }
 
-func run(args []string, out io.Writer) error {
+func run(args []string, out, errOut io.Writer) error {
+	if len(args) > 1 {
+		switch args[1] {
+		case "convert":
+			return runConvert(args[0]+" convert", args[2:], out)
+		case "summary":
+			return runSummary(args[0]+" summary", args[2:], out, errOut)
+		case "diff":
+			return runDiff(args[0]+" diff", args[2:], out, errOut)
+		case "shard":
+			return runShard(args[0]+" shard", args[2:], out, errOut)
+		case "validate":
+			return runValidate(args[0]+" validate", args[2:], out, errOut)
+		}
+	}
+	// convert is the default so sure can be used without a subcommand
+	return runConvert(args[0], args[1:], out)
+}
+
+// newFlagSet creates a flag set that returns errors instead of exiting.
+func newFlagSet(name string) *flag.FlagSet {
 	// ExitOnError makes the error message look cleaner to the user
 	// but makes testing hard. ContinueOnError allows me to capture the
 	// returned error. Unfortunately, flag will print the error and usage and
 	// main() will print the error again.
 	// TODO is there a way to handle this better?
-	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
-	src := flags.String("src", "", "Source directory containing Maven Surefire XML reports.")
-	dest := flags.String("dest", "", "Destination directory where CSV(s) will be written to. It will be created if does not exist.")
-	concat := flags.Bool("concat", false, "Concatenate all Maven Surefire XML reports into one CSV file.")
+	return flag.NewFlagSet(name, flag.ContinueOnError)
+}
+
+func runConvert(name string, args []string, out io.Writer) error {
+	flags := newFlagSet(name)
+	src := flags.String("src", "", "Source directory or .zip, .tar, .tar.gz or .tgz archive containing Maven Surefire XML reports. Archives in the directory are read as well.")
+	dest := flags.String("dest", "", "Destination directory where converted reports will be written to. It will be created if does not exist. The database file if the format is sqlite.")
+	concat := flags.Bool("concat", false, "Concatenate all Maven Surefire XML reports into one file. Implied by format openmetrics.")
+	format := flags.String("format", string(surefire.FormatCSV), fmt.Sprintf("Format to convert reports to. One of %v.", surefire.Formats()))
+	stackTrace := flags.Bool("stacktrace", false, "Add the stack trace of failed or errored tests as a column.")
+	attempts := flags.Bool("attempts", false, "Write one row per run of a test instead of one per test. Tests are rerun if Surefire is configured with rerunFailingTestsCount.")
+	columns := flags.String("columns", "", "Comma-separated CSV columns to write in the given order instead of the default ones. Valid columns are "+strings.Join(surefire.Columns(), ", ")+".")
+	var properties stringsFlag
+	flags.Var(&properties, "property", "Add the test suite property with the given name as a CSV column. Can be a glob pattern like java.* and be given more than once.")
+	var include, exclude stringsFlag
+	flags.Var(&include, "include", "Convert the reports matching the glob pattern relative to src. A ** matches any number of directories. Can be given more than once. Defaults to "+strings.Join(defaultIncludes, " and ")+".")
+	flags.Var(&exclude, "exclude", "Do not convert the reports matching the glob pattern relative to src even if they are included. Can be given more than once.")
+	followSymlinks := flags.Bool("follow-symlinks", false, "Convert reports in directories symbolic links point to.")
+	output := flags.Bool("output", false, "Write the system-out and system-err of every test into files in an output directory in dest. Adds columns with the files and the size of the output. Only supported by format csv.")
+	strict := flags.Bool("strict", false, "Print a summary of all files that failed to convert and exit with a non-zero code if any did.")
+	rowGroupSize := flags.Int("row-group-size", 128*1024, "Maximum number of rows in a row group of format parquet. Rows are held in memory until their row group is written.")
+	aggregate := flags.String("aggregate", string(surefire.LevelTest), fmt.Sprintf("Level format openmetrics aggregates tests at to keep the number of series under control. One of %v.", surefire.Levels()))
+	workers := flags.Int("workers", runtime.GOMAXPROCS(0), "Number of reports to decode in parallel.")
 	debug := flags.Bool("debug", false, "Print debug information.")
-	err := flags.Parse(args[1:])
+	err := flags.Parse(args)
 	if err != nil {
 		return err
 	}
@@ -38,11 +79,262 @@ func run(args []string, out io.Writer) error {
 	if *dest == "" {
 		return errors.New("dest must be provided")
 	}
+	if len(include) == 0 {
+		include = defaultIncludes
+	}
 
-	return surefire.CsvConverter{
-		From:   *src,
-		Concat: *concat,
-		Log:    out,
-		Debug:  *debug,
+	return surefire.Converter{
+		From:           *src,
+		Concat:         *concat,
+		Log:            out,
+		Debug:          *debug,
+		Format:         surefire.Format(*format),
+		StackTrace:     *stackTrace,
+		Attempts:       *attempts,
+		Output:         *output,
+		Columns:        split(*columns),
+		Properties:     properties,
+		Include:        include,
+		Exclude:        exclude,
+		FollowSymlinks: *followSymlinks,
+		Strict:         *strict,
+		RowGroupSize:   *rowGroupSize,
+		Aggregate:      surefire.Level(*aggregate),
+		Workers:        *workers,
 	}.To(*dest)
 }
+
+// defaultIncludes are the reports written by the Surefire and Failsafe
+// plugins.
+var defaultIncludes = []string{"**/surefire-reports/TEST-*.xml", "**/failsafe-reports/TEST-*.xml"}
+
+// stringsFlag is a flag that can be given more than once.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// split splits a comma-separated flag value ignoring empty values.
+func split(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func runValidate(name string, args []string, out, errOut io.Writer) error {
+	flags := newFlagSet(name)
+	src := flags.String("src", "", "Source directory containing Maven Surefire XML reports.")
+	var include, exclude stringsFlag
+	flags.Var(&include, "include", "Validate the files matching the glob pattern relative to src. A ** matches any number of directories. Can be given more than once. Defaults to "+strings.Join(defaultIncludes, " and ")+".")
+	flags.Var(&exclude, "exclude", "Do not validate the files matching the glob pattern relative to src even if they are included. Can be given more than once.")
+	followSymlinks := flags.Bool("follow-symlinks", false, "Validate files in directories symbolic links point to.")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *src == "" {
+		return errors.New("src must be provided")
+	}
+	if len(include) == 0 {
+		include = defaultIncludes
+	}
+
+	validations, err := surefire.Validator{
+		From:           *src,
+		Include:        include,
+		Exclude:        exclude,
+		FollowSymlinks: *followSymlinks,
+		Log:            errOut,
+	}.Validate()
+	if err != nil {
+		return err
+	}
+	if err := validations.WriteTable(out); err != nil {
+		return err
+	}
+
+	// an invalid report exits with a non-zero code so CI can gate on it
+	if n := len(validations.Invalid()); n > 0 {
+		return fmt.Errorf("found %d invalid reports", n)
+	}
+	return nil
+}
+
+func runSummary(name string, args []string, out, errOut io.Writer) error {
+	flags := newFlagSet(name)
+	src := flags.String("src", "", "Source directory containing Maven Surefire XML reports.")
+	format := flags.String("format", "table", "Format to print the summary in. One of [table csv markdown]. Markdown is kept under the 65536 characters GitHub allows in a comment.")
+	var include, exclude stringsFlag
+	flags.Var(&include, "include", "Summarize the reports matching the glob pattern relative to src. A ** matches any number of directories. Can be given more than once. Defaults to "+strings.Join(defaultIncludes, " and ")+".")
+	flags.Var(&exclude, "exclude", "Do not summarize the reports matching the glob pattern relative to src even if they are included. Can be given more than once.")
+	followSymlinks := flags.Bool("follow-symlinks", false, "Summarize reports in directories symbolic links point to.")
+	debug := flags.Bool("debug", false, "Print debug information.")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *src == "" {
+		return errors.New("src must be provided")
+	}
+	if *format != "table" && *format != "csv" && *format != "markdown" {
+		return fmt.Errorf("unknown format %q, valid formats are [table csv markdown]", *format)
+	}
+	if len(include) == 0 {
+		include = defaultIncludes
+	}
+
+	summary, err := surefire.Summarizer{
+		From:           *src,
+		Include:        include,
+		Exclude:        exclude,
+		FollowSymlinks: *followSymlinks,
+		Log:            errOut,
+		Debug:          *debug,
+	}.Summarize()
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "csv":
+		return summary.WriteCSV(out)
+	case "markdown":
+		return summary.WriteMarkdown(out)
+	}
+	return summary.WriteTable(out)
+}
+
+func runDiff(name string, args []string, out, errOut io.Writer) error {
+	flags := newFlagSet(name)
+	base := flags.String("base", "", "Source directory containing the Maven Surefire XML reports to compare against.")
+	head := flags.String("head", "", "Source directory containing the Maven Surefire XML reports to compare.")
+	absolute := flags.Float64("threshold-seconds", 0, "Report tests that got slower by at least this many seconds as regressions.")
+	percent := flags.Float64("threshold-percent", 0, "Report tests that got slower by at least this many percent as regressions.")
+	failOnRemoved := flags.Bool("fail-on-removed", false, "Report tests that are in base but not in head as regressions.")
+	format := flags.String("format", "table", "Format to print the diff in. One of [table csv].")
+	var include, exclude stringsFlag
+	flags.Var(&include, "include", "Compare the reports matching the glob pattern relative to base and head. A ** matches any number of directories. Can be given more than once. Defaults to "+strings.Join(defaultIncludes, " and ")+".")
+	flags.Var(&exclude, "exclude", "Do not compare the reports matching the glob pattern relative to base and head even if they are included. Can be given more than once.")
+	followSymlinks := flags.Bool("follow-symlinks", false, "Compare reports in directories symbolic links point to.")
+	debug := flags.Bool("debug", false, "Print debug information.")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *base == "" {
+		return errors.New("base must be provided")
+	}
+	if *head == "" {
+		return errors.New("head must be provided")
+	}
+	if *absolute < 0 || *percent < 0 {
+		return errors.New("thresholds must not be negative")
+	}
+	if *format != "table" && *format != "csv" {
+		return fmt.Errorf("unknown format %q, valid formats are [table csv]", *format)
+	}
+	if len(include) == 0 {
+		include = defaultIncludes
+	}
+
+	diff, err := surefire.Differ{
+		Base:           *base,
+		Head:           *head,
+		Include:        include,
+		Exclude:        exclude,
+		FollowSymlinks: *followSymlinks,
+		Log:            errOut,
+		Debug:          *debug,
+		Thresholds:     surefire.Thresholds{Absolute: *absolute, Percent: *percent},
+		FailOnRemoved:  *failOnRemoved,
+	}.Diff()
+	if err != nil {
+		return err
+	}
+
+	if *format == "csv" {
+		err = diff.WriteCSV(out)
+	} else {
+		err = diff.WriteTable(out)
+	}
+	if err != nil {
+		return err
+	}
+
+	// a regression exits with a non-zero code so CI can gate on it
+	if n := len(diff.Regressions()); n > 0 {
+		return fmt.Errorf("found %d regressions", n)
+	}
+	return nil
+}
+
+func runShard(name string, args []string, out, errOut io.Writer) error {
+	flags := newFlagSet(name)
+	src := flags.String("src", "", "Source directory containing Maven Surefire XML reports.")
+	n := flags.Int("n", 0, "Number of shards to partition the test classes into. Must not exceed the number of test classes.")
+	format := flags.String("format", "table", "Format to print the shards in. One of [table test json includes]. includes writes one Surefire includesFile per shard into dest.")
+	dest := flags.String("dest", "", "Destination directory the includesFile of every shard is written to if the format is includes.")
+	var include, exclude stringsFlag
+	flags.Var(&include, "include", "Shard the classes of the reports matching the glob pattern relative to src. A ** matches any number of directories. Can be given more than once. Defaults to "+strings.Join(defaultIncludes, " and ")+".")
+	flags.Var(&exclude, "exclude", "Do not shard the classes of the reports matching the glob pattern relative to src even if they are included. Can be given more than once.")
+	followSymlinks := flags.Bool("follow-symlinks", false, "Shard the classes of reports in directories symbolic links point to.")
+	debug := flags.Bool("debug", false, "Print debug information.")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *src == "" {
+		return errors.New("src must be provided")
+	}
+	if *n < 1 {
+		return errors.New("n must be at least 1")
+	}
+	switch *format {
+	case "table", "test", "json":
+	case "includes":
+		if *dest == "" {
+			return errors.New("dest must be provided if the format is includes")
+		}
+	default:
+		return fmt.Errorf("unknown format %q, valid formats are [table test json includes]", *format)
+	}
+
+	if len(include) == 0 {
+		include = defaultIncludes
+	}
+
+	plan, err := surefire.Sharder{
+		From:           *src,
+		Include:        include,
+		Exclude:        exclude,
+		FollowSymlinks: *followSymlinks,
+		Log:            errOut,
+		Debug:          *debug,
+		Shards:         *n,
+	}.Plan()
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "test":
+		return plan.WriteTests(out)
+	case "json":
+		return plan.WriteJSON(out)
+	case "includes":
+		if err := plan.WriteIncludes(*dest); err != nil {
+			return err
+		}
+	}
+	return plan.WriteTable(out)
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -34,13 +36,211 @@ func TestRun(t *testing.T) {
 			},
 			err: "dest must be provided",
 		},
+		"UnknownColumnIsRejected": {
+			args: []string{
+				"sure",
+				"-src",
+				"surefire/testdata/input",
+				"-dest",
+				t.TempDir(),
+				"-columns",
+				"test, owner",
+			},
+			err: `unknown column "owner"`,
+		},
+		"InvalidIncludeIsRejected": {
+			args: []string{
+				"sure",
+				"-src",
+				"surefire/testdata/input",
+				"-dest",
+				t.TempDir(),
+				"-include",
+				"**/[a-.xml",
+			},
+			err: `invalid pattern "**/[a-.xml"`,
+		},
+		"ConvertSubcommandSrcIsMandatory": {
+			args: []string{
+				"sure",
+				"convert",
+				"-dest",
+				t.TempDir(),
+			},
+			err: "src must be provided",
+		},
+		"SummarySrcIsMandatory": {
+			args: []string{
+				"sure",
+				"summary",
+			},
+			err: "src must be provided",
+		},
+		"SummaryFailsOnUnknownFormat": {
+			args: []string{
+				"sure",
+				"summary",
+				"-src",
+				"surefire/testdata/input",
+				"-format",
+				"xml",
+			},
+			err: "unknown format",
+		},
+		"DiffBaseIsMandatory": {
+			args: []string{
+				"sure",
+				"diff",
+				"-head",
+				"surefire/testdata/input",
+			},
+			err: "base must be provided",
+		},
+		"DiffHeadIsMandatory": {
+			args: []string{
+				"sure",
+				"diff",
+				"-base",
+				"surefire/testdata/input",
+			},
+			err: "head must be provided",
+		},
+		"DiffWithoutRegressions": {
+			args: []string{
+				"sure",
+				"diff",
+				"-base",
+				"surefire/testdata/input",
+				"-head",
+				"surefire/testdata/input",
+				"-threshold-seconds",
+				"0.1",
+				"-include",
+				"*.xml",
+			},
+		},
+		"DiffFailsOnRemovedTestsIfEnabled": {
+			args: []string{
+				"sure",
+				"diff",
+				"-base",
+				"surefire/testdata/input",
+				"-head",
+				t.TempDir(),
+				"-fail-on-removed",
+				"-include",
+				"*.xml",
+			},
+			err: "found 5 regressions",
+		},
+		"ShardNIsMandatory": {
+			args: []string{
+				"sure",
+				"shard",
+				"-src",
+				"surefire/testdata/input",
+			},
+			err: "n must be at least 1",
+		},
+		"ShardIncludesNeedsDest": {
+			args: []string{
+				"sure",
+				"shard",
+				"-src",
+				"surefire/testdata/input",
+				"-n",
+				"2",
+				"-format",
+				"includes",
+			},
+			err: "dest must be provided",
+		},
+		"ShardNExceedingClassesIsRejected": {
+			args: []string{
+				"sure",
+				"shard",
+				"-src",
+				"surefire/testdata/input",
+				"-n",
+				"3",
+				"-include",
+				"*.xml",
+			},
+			err: "exceeds the number of test classes",
+		},
+		"Shard": {
+			args: []string{
+				"sure",
+				"shard",
+				"-src",
+				"surefire/testdata/input",
+				"-n",
+				"2",
+				"-format",
+				"json",
+				"-include",
+				"*.xml",
+			},
+		},
+		"ShardOnlyIncludesSurefireAndFailsafeReportsByDefault": {
+			args: []string{
+				"sure",
+				"shard",
+				"-src",
+				"surefire/testdata/input",
+				"-n",
+				"1",
+			},
+			err: "number of test classes 0",
+		},
+		"ValidateSrcIsMandatory": {
+			args: []string{
+				"sure",
+				"validate",
+			},
+			err: "src must be provided",
+		},
+		"Validate": {
+			args: []string{
+				"sure",
+				"validate",
+				"-src",
+				"surefire/testdata/input",
+				"-include",
+				"*.xml",
+			},
+		},
+		"Summary": {
+			args: []string{
+				"sure",
+				"summary",
+				"-src",
+				"surefire/testdata/input",
+				"-format",
+				"csv",
+				"-include",
+				"*.xml",
+			},
+		},
+		"SummaryMarkdown": {
+			args: []string{
+				"sure",
+				"summary",
+				"-src",
+				"surefire/testdata/input",
+				"-format",
+				"markdown",
+				"-include",
+				"*.xml",
+			},
+		},
 	}
 
 	for k, tc := range tc {
 		t.Run(k, func(t *testing.T) {
 			var out bytes.Buffer
 
-			err := run(tc.args, &out)
+			err := run(tc.args, &out, &out)
 
 			if tc.err != "" {
 				if err == nil {
@@ -56,3 +256,44 @@ func TestRun(t *testing.T) {
 		})
 	}
 }
+
+func TestRunConvert(t *testing.T) {
+	t.Run("WritesCSVs", func(t *testing.T) {
+		dest := t.TempDir()
+		var out bytes.Buffer
+
+		err := run([]string{"sure", "convert", "-src", "surefire/testdata/input", "-dest", dest, "-include", "*.xml"}, &out, &out)
+		if err != nil {
+			t.Fatalf("expected no error but got: %s", err)
+		}
+
+		files, err := filepath.Glob(filepath.Join(dest, "*.csv"))
+		if err != nil {
+			t.Fatalf("expected no error but got: %s", err)
+		}
+		if len(files) != 2 {
+			t.Errorf("got %d CSVs in %q but want 2", len(files), dest)
+		}
+	})
+	t.Run("LogsIfNoReportsMatch", func(t *testing.T) {
+		dest := t.TempDir()
+		var out bytes.Buffer
+
+		err := run([]string{"sure", "convert", "-src", "surefire/testdata/input", "-dest", dest}, &out, &out)
+		if err != nil {
+			t.Fatalf("expected no error but got: %s", err)
+		}
+
+		want := `Found no reports in "surefire/testdata/input" matching the include patterns`
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("got output %q but want it to contain %q", out.String(), want)
+		}
+		files, err := os.ReadDir(dest)
+		if err != nil {
+			t.Fatalf("expected no error but got: %s", err)
+		}
+		if len(files) != 0 {
+			t.Errorf("got %d files in %q but want none", len(files), dest)
+		}
+	})
+}
@@ -0,0 +1,220 @@
+package surefire
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// archiveExts are the extensions of the archives reports are read from.
+var archiveExts = []string{".zip", ".tar", ".tar.gz", ".tgz"}
+
+// isArchive returns true if file is an archive reports can be read from.
+func isArchive(file string) bool {
+	name := strings.ToLower(file)
+	for _, ext := range archiveExts {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasArchives returns true if from is an archive or a directory containing
+// archives that are not excluded by filter.
+func hasArchives(from string, filter fileFilter) bool {
+	if isArchive(from) {
+		return true
+	}
+	absFrom, err := filepath.Abs(from)
+	if err != nil {
+		return false
+	}
+	found := errors.New("found archive")
+	err = filepath.WalkDir(from, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != from && skipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isArchive(path) {
+			return nil
+		}
+		rel, err := filepath.Rel(from, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		abs := filepath.ToSlash(filepath.Join(absFrom, rel))
+		if rel == "." {
+			rel = filepath.Base(from)
+		}
+		if filter.excluded(rel, abs) {
+			return nil
+		}
+		return found
+	})
+	return err == found
+}
+
+// archiveDir returns the path of the archive in file without its extension.
+// Files at the root of an archive are matched as if they were in a directory
+// of that name so **/surefire-reports/*.xml matches the files at the root of
+// surefire-reports.zip.
+func archiveDir(file string) string {
+	name := strings.ToLower(file)
+	for _, ext := range archiveExts {
+		if strings.HasSuffix(name, ext) {
+			return file[:len(file)-len(ext)]
+		}
+	}
+	return file
+}
+
+// archiveFS is the file system of an archive which is only open while its
+// files are read. It is opened by the first call to Open and closed once
+// all pending files are done so only the archives currently read are held
+// open or in memory. It is safe to be used by multiple goroutines.
+type archiveFS struct {
+	path string
+
+	mu     sync.Mutex
+	fsys   fs.FS
+	closer io.Closer
+	// pending is the number of files that have not been read yet.
+	pending int
+}
+
+func (a *archiveFS) Open(name string) (fs.File, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.open(); err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return a.fsys.Open(name)
+}
+
+// open opens the archive unless it is open already. The caller must hold
+// the lock.
+func (a *archiveFS) open() error {
+	if a.fsys != nil {
+		return nil
+	}
+	fsys, closer, err := openArchive(a.path)
+	if err != nil {
+		return err
+	}
+	a.fsys, a.closer = fsys, closer
+	return nil
+}
+
+// done marks a pending file as read and closes the archive once all of them
+// are read. It is opened again if files are opened after that.
+func (a *archiveFS) done() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.pending--
+	if a.pending <= 0 {
+		// the archive is only read so there is nothing to lose if closing
+		// fails
+		_ = a.close()
+	}
+}
+
+func (a *archiveFS) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.close()
+}
+
+// close closes the archive if it is open. The caller must hold the lock.
+func (a *archiveFS) close() error {
+	if a.fsys == nil {
+		return nil
+	}
+	err := a.closer.Close()
+	a.fsys, a.closer = nil, nil
+	return err
+}
+
+// openArchive opens the archive in file as file system. The returned closer
+// must be called once the file system is no longer used.
+func openArchive(file string) (fs.FS, io.Closer, error) {
+	if strings.HasSuffix(strings.ToLower(file), ".zip") {
+		r, err := zip.OpenReader(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		return r, r, nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if name := strings.ToLower(file); strings.HasSuffix(name, ".gz") || strings.HasSuffix(name, ".tgz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	fsys, err := tarFS(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return fsys, io.NopCloser(nil), nil
+}
+
+// tarFS reads the XML files in the tar archive in r into a file system. A tar
+// archive can only be read sequentially so its XML files are kept in memory
+// in an uncompressed zip archive which provides the file system. Other files
+// are skipped as they are never read.
+func tarFS(r io.Reader) (fs.FS, error) {
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if h.Typeflag != tar.TypeReg || strings.ToLower(path.Ext(h.Name)) != ".xml" {
+			continue
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: strings.TrimPrefix(path.Clean(h.Name), "/"), Method: zip.Store, Modified: h.ModTime})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.Copy(w, tr); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+}
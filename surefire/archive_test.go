@@ -0,0 +1,314 @@
+package surefire
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// artifact holds the files of a CI artifact by their name.
+var artifact = map[string]string{
+	"pom.xml": `<project>
+  <groupId>org.hisp.dhis</groupId>
+  <artifactId>dhis-web-api</artifactId>
+</project>`,
+	"target/surefire-reports/TEST-org.hisp.dhis.ApiTest.xml": `<testsuite name="org.hisp.dhis.ApiTest" time="1" tests="1" errors="0" skipped="0" failures="0">
+  <testcase name="test" classname="org.hisp.dhis.ApiTest" time="1"/>
+</testsuite>`,
+	"target/surefire-reports/org.hisp.dhis.ApiTest.txt": "Tests run: 1",
+}
+
+func writeZip(t *testing.T, name string, files map[string]string) {
+	t.Helper()
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for _, n := range slices.Sorted(maps.Keys(files)) {
+		w, err := zw.Create(n)
+		if err != nil {
+			t.Fatalf("failed to create %q in zip due to %s", n, err)
+		}
+		if _, err := io.WriteString(w, files[n]); err != nil {
+			t.Fatalf("failed to write %q in zip due to %s", n, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to write zip due to %s", err)
+	}
+	if err := os.WriteFile(name, b.Bytes(), 0600); err != nil {
+		t.Fatalf("failed to write zip due to %s", err)
+	}
+}
+
+func writeTarGz(t *testing.T, name string, files map[string]string) {
+	t.Helper()
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gz)
+	for _, n := range slices.Sorted(maps.Keys(files)) {
+		if err := tw.WriteHeader(&tar.Header{Name: "./" + n, Mode: 0600, Size: int64(len(files[n])), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("failed to write %q in tar due to %s", n, err)
+		}
+		if _, err := io.WriteString(tw, files[n]); err != nil {
+			t.Fatalf("failed to write %q in tar due to %s", n, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to write tar due to %s", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("failed to write tar due to %s", err)
+	}
+	if err := os.WriteFile(name, b.Bytes(), 0600); err != nil {
+		t.Fatalf("failed to write tar due to %s", err)
+	}
+}
+
+func TestIsArchive(t *testing.T) {
+	tc := map[string]bool{
+		"reports.zip":    true,
+		"reports.ZIP":    true,
+		"reports.tar":    true,
+		"reports.tar.gz": true,
+		"reports.tgz":    true,
+		"reports.gz":     false,
+		"TEST-a.xml":     false,
+	}
+
+	for in, want := range tc {
+		t.Run(in, func(t *testing.T) {
+			if got := isArchive(in); got != want {
+				t.Errorf("isArchive(%q) = %t but want %t", in, got, want)
+			}
+		})
+	}
+}
+
+func TestConverterArchives(t *testing.T) {
+	src := t.TempDir()
+	zipped := filepath.Join(src, "api-reports.zip")
+	writeZip(t, zipped, artifact)
+	tarred := filepath.Join(src, "api-reports.tar.gz")
+	writeTarGz(t, tarred, artifact)
+
+	t.Run("ConvertsReportsInArchives", func(t *testing.T) {
+		var w bytes.Buffer
+		c := Converter{
+			From:    src,
+			Log:     &w,
+			Concat:  true,
+			Strict:  true,
+			Include: []string{"**/surefire-reports/TEST-*.xml"},
+			Columns: []string{"module", "class", "archive"},
+		}
+		dest := t.TempDir()
+
+		err := c.To(dest)
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+		if w.Len() > 0 {
+			t.Errorf("expected no logs but got %q", w.String())
+		}
+
+		got := readCSV(t, filepath.Join(dest, "surefire.csv"))
+		want := [][]string{
+			{"module", "class", "archive"},
+			{"dhis-web-api", "org.hisp.dhis.ApiTest", tarred},
+			{"dhis-web-api", "org.hisp.dhis.ApiTest", zipped},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("To() mismatch (-want +got): \n%s", diff)
+		}
+	})
+
+	t.Run("ConvertsReportsWithTheSamePathIntoSeparateFiles", func(t *testing.T) {
+		var w bytes.Buffer
+		c := Converter{
+			From:    src,
+			Log:     &w,
+			Strict:  true,
+			Include: []string{"**/surefire-reports/TEST-*.xml"},
+			Columns: []string{"class", "archive"},
+		}
+		dest := t.TempDir()
+
+		err := c.To(dest)
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		for _, archive := range []string{zipped, tarred} {
+			got := readCSV(t, filepath.Join(dest, filepath.Base(archive)+"_TEST-org.hisp.dhis.ApiTest.csv"))
+			want := [][]string{
+				{"class", "archive"},
+				{"org.hisp.dhis.ApiTest", archive},
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("To() mismatch (-want +got): \n%s", diff)
+			}
+		}
+	})
+
+	t.Run("WritesArchiveColumnByDefault", func(t *testing.T) {
+		var w bytes.Buffer
+		c := Converter{
+			From:    zipped,
+			Log:     &w,
+			Concat:  true,
+			Strict:  true,
+			Include: []string{"**/surefire-reports/TEST-*.xml"},
+		}
+		dest := t.TempDir()
+
+		err := c.To(dest)
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		records := readCSV(t, filepath.Join(dest, "surefire.csv"))
+		var got []string
+		for _, r := range records {
+			got = append(got, r[len(r)-1])
+		}
+		want := []string{"archive", zipped}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("To() mismatch (-want +got): \n%s", diff)
+		}
+	})
+
+	t.Run("ConvertsArchiveGivenAsSrc", func(t *testing.T) {
+		var got []string
+		_, err := walk(zipped, io.Discard, false, walkOptions{}, func(path string, suites []TestSuite) error {
+			got = append(got, path)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		want := []string{filepath.Join(zipped, "target", "surefire-reports", "TEST-org.hisp.dhis.ApiTest.xml")}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("walk() mismatch (-want +got): \n%s", diff)
+		}
+	})
+
+	t.Run("ConvertsReportsAtTheRootOfArchives", func(t *testing.T) {
+		src := t.TempDir()
+		zipped := filepath.Join(src, "surefire-reports.zip")
+		writeZip(t, zipped, map[string]string{
+			"TEST-org.hisp.dhis.ApiTest.xml": artifact["target/surefire-reports/TEST-org.hisp.dhis.ApiTest.xml"],
+		})
+		var w bytes.Buffer
+		c := Converter{
+			From:    src,
+			Log:     &w,
+			Concat:  true,
+			Strict:  true,
+			Include: []string{"**/surefire-reports/TEST-*.xml"},
+			Columns: []string{"class", "archive"},
+		}
+		dest := t.TempDir()
+
+		err := c.To(dest)
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+		if w.Len() > 0 {
+			t.Errorf("expected no logs but got %q", w.String())
+		}
+
+		got := readCSV(t, filepath.Join(dest, "surefire.csv"))
+		want := [][]string{
+			{"class", "archive"},
+			{"org.hisp.dhis.ApiTest", zipped},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("To() mismatch (-want +got): \n%s", diff)
+		}
+	})
+
+	t.Run("LogsArchivesWithoutReports", func(t *testing.T) {
+		src := t.TempDir()
+		zipped := filepath.Join(src, "test-results.zip")
+		writeZip(t, zipped, map[string]string{
+			"TEST-org.hisp.dhis.ApiTest.xml": artifact["target/surefire-reports/TEST-org.hisp.dhis.ApiTest.xml"],
+		})
+		var w bytes.Buffer
+
+		_, err := walk(src, &w, false, walkOptions{filter: fileFilter{include: []string{"**/surefire-reports/TEST-*.xml"}}}, func(path string, suites []TestSuite) error {
+			t.Errorf("expected no reports but got %q", path)
+			return nil
+		})
+
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+		if !strings.Contains(w.String(), "Found no reports in "+strconv.Quote(zipped)) {
+			t.Errorf("expected the archive to be logged but got %q", w.String())
+		}
+	})
+
+	t.Run("OpensArchivesOnlyWhileReadingThem", func(t *testing.T) {
+		found, _, err := reports(src, io.Discard, fileFilter{}, true)
+		defer found.Close()
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+		open := func() []bool {
+			var got []bool
+			for _, a := range found.archives {
+				got = append(got, a.(*archiveFS).fsys != nil)
+			}
+			return got
+		}
+
+		if diff := cmp.Diff([]bool{false, false}, open()); diff != "" {
+			t.Fatalf("expected archives to be closed once searched (-want +got): \n%s", diff)
+		}
+		for i, rf := range found.files {
+			if _, err := decodeFile(rf, false); err != nil && !errors.Is(err, ErrNotReport) {
+				t.Fatalf("expected no error but got %s", err)
+			}
+			rf.done()
+			// the files of the tar archive come first
+			want := []bool{i < 1, false}
+			if i >= 2 {
+				want = []bool{false, i < 3}
+			}
+			if diff := cmp.Diff(want, open()); diff != "" {
+				t.Errorf("archives open after reading %q mismatch (-want +got): \n%s", rf.path, diff)
+			}
+		}
+	})
+
+	t.Run("ReportsArchivesWhichCannotBeRead", func(t *testing.T) {
+		src := t.TempDir()
+		broken := filepath.Join(src, "broken.zip")
+		if err := os.WriteFile(broken, []byte("not a zip"), 0600); err != nil {
+			t.Fatalf("failed to write archive due to %s", err)
+		}
+
+		failed, err := walk(src, io.Discard, false, walkOptions{}, func(path string, suites []TestSuite) error {
+			return nil
+		})
+
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+		if len(failed) != 1 || failed[0].Path != broken || failed[0].Phase != PhaseOpen {
+			t.Errorf("expected open error for %q instead got %v", broken, failed)
+		}
+	})
+}
@@ -0,0 +1,626 @@
+package surefire
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Format is the output format reports are converted to.
+type Format string
+
+const (
+	FormatCSV Format = "csv"
+	// FormatJSON writes one JSON document per test suite or one array of
+	// them if reports are concatenated.
+	FormatJSON Format = "json"
+	// FormatNDJSON writes one JSON document per line and test case.
+	FormatNDJSON Format = "ndjson"
+	// FormatSQLite appends the reports to an SQLite database as a new run.
+	FormatSQLite Format = "sqlite"
+	// FormatHTML writes a self-contained HTML report with sortable tables of
+	// modules, classes and tests, a duration histogram, the slowest tests and
+	// failure details.
+	FormatHTML Format = "html"
+	// FormatXLSX writes an Excel workbook with a summary sheet and a sheet
+	// per module with numeric cells for durations and counters.
+	FormatXLSX Format = "xlsx"
+	// FormatParquet writes one Parquet row per test case with a typed schema
+	// for loading into data lakes.
+	FormatParquet Format = "parquet"
+	// FormatOpenMetrics writes gauges of test durations and counts in the
+	// OpenMetrics text format for the textfile collector of node_exporter.
+	// Reports are always concatenated as series are aggregated across
+	// reports and must not be repeated in several files.
+	FormatOpenMetrics Format = "openmetrics"
+)
+
+// Formats returns all supported output formats.
+func Formats() []Format {
+	return []Format{FormatCSV, FormatJSON, FormatNDJSON, FormatSQLite, FormatHTML, FormatXLSX, FormatParquet, FormatOpenMetrics}
+}
+
+// tabular returns true if the format writes the columns of FormatCSV.
+func (f Format) tabular() bool {
+	return f == FormatCSV || f == "" || f == FormatHTML || f == FormatXLSX
+}
+
+// single returns true if the format writes all reports into one file even if
+// they are not concatenated.
+func (f Format) single() bool {
+	return f == FormatOpenMetrics
+}
+
+// streams returns true if the encoders of the format are caseEncoders.
+func (f Format) streams() bool {
+	return f == FormatCSV || f == "" || f == FormatNDJSON || f == FormatParquet || f == FormatOpenMetrics
+}
+
+// Converter converts Maven Surefire XML reports found in From.
+type Converter struct {
+	From string
+	// Concat writes all reports into one file. Reports are written in the
+	// order of their paths so the test cases of a report are held in memory
+	// until it is written even by formats that encode test cases one at a
+	// time.
+	Concat bool
+	Log    io.Writer
+	Debug  bool
+	// Format defaults to FormatCSV.
+	Format Format
+	// StackTrace adds the stack trace of failed or errored tests as a column.
+	StackTrace bool
+	// Attempts writes one row per run of a test case instead of one row per
+	// test case. Tests are only run more than once if Surefire is configured
+	// to rerun failing tests.
+	Attempts bool
+	// Strict makes To return all files that failed to convert as FileErrors.
+	// Failures are logged and do not stop the conversion either way.
+	Strict bool
+	// Output writes the system-out and system-err of every test case into
+	// files in the output directory next to the converted reports. Columns
+	// for the files and the size of the output are added. It is only
+	// supported by FormatCSV.
+	Output bool
+	// Columns are the names of the CSV columns to write in the given order.
+	// Valid names are returned by Columns. Columns replaces the default
+	// columns and the ones added by StackTrace and Output.
+	Columns []string
+	// Properties are the names of test suite properties to add as CSV
+	// columns after the other columns. Names can be glob patterns like
+	// java.* matching the properties found in any report. Properties missing
+	// in a report are left empty. FormatParquet writes them into a
+	// repeated field of name and value pairs instead.
+	Properties []string
+	// RowGroupSize is the maximum number of rows in a row group of
+	// FormatParquet. Rows are held in memory until their row group is
+	// written. It defaults to 131072 rows.
+	RowGroupSize int
+	// Aggregate is the level FormatOpenMetrics aggregates test cases at to
+	// keep the number of series under control. It defaults to LevelTest.
+	Aggregate Level
+	// Include are glob patterns of the reports to convert relative to From
+	// using forward slashes. A ** matches any number of directories like
+	// **/surefire-reports/TEST-*.xml. All XML files are converted if
+	// Include is empty. Failsafe summaries are found regardless of Include.
+	Include []string
+	// Exclude are glob patterns of reports and Failsafe summaries not to
+	// convert even if they are included.
+	Exclude []string
+	// FollowSymlinks converts reports in directories symbolic links point
+	// to. Directories like .git and node_modules are never walked.
+	FollowSymlinks bool
+	// Workers is the number of reports decoded in parallel. It defaults to
+	// GOMAXPROCS. Reports are written in the order of their paths regardless
+	// of the number of workers. Reports of formats that encode test cases one
+	// at a time are written by the workers while they are decoded unless
+	// they are concatenated. Output files of test cases with the same names
+	// in different reports are then numbered in the order they are written.
+	Workers int
+}
+
+// CsvConverter converts Maven Surefire XML reports to CSV.
+//
+// Deprecated: use Converter which defaults to CSV.
+type CsvConverter = Converter
+
+// To converts the reports into dest. dest is a directory the converted
+// reports are written to, or the database file if the format is
+// FormatSQLite.
+func (cc Converter) To(dest string) (err error) {
+	converter, err := cc.converter(dest)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := converter.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	opts := walkOptions{workers: cc.Workers, output: cc.Output, filter: cc.filter(), summary: converter.writeSummary}
+	if c, ok := converter.(caseConverter); ok && cc.Format.streams() {
+		opts.cases = c.writeCases
+		if d, ok := converter.(discarder); ok {
+			opts.discard = d.discard
+		}
+		// reports written into their own files do not need to wait for
+		// each other
+		_, opts.concurrent = converter.(*separateConverter)
+	}
+	if sc, ok := converter.(*separateConverter); ok {
+		opts.found = sc.name
+	}
+	failed, err := walk(cc.From, cc.Log, cc.Debug, opts, converter.write)
+	if err != nil {
+		return err
+	}
+	if cc.Strict && len(failed) > 0 {
+		return failed
+	}
+	return nil
+}
+
+// filter returns the filter selecting the reports to convert.
+func (cc Converter) filter() fileFilter {
+	return fileFilter{include: cc.Include, exclude: cc.Exclude, followSymlinks: cc.FollowSymlinks}
+}
+
+func (cc Converter) converter(dest string) (converter, error) {
+	if err := cc.filter().validate(); err != nil {
+		return nil, err
+	}
+	if cc.Output && cc.Format != FormatCSV && cc.Format != "" {
+		return nil, fmt.Errorf("output is only supported by format %q", FormatCSV)
+	}
+	if len(cc.Properties) > 0 && !cc.Format.tabular() && cc.Format != FormatParquet && cc.Format != FormatOpenMetrics {
+		return nil, fmt.Errorf("properties are only supported by formats %v", []Format{FormatCSV, FormatHTML, FormatXLSX, FormatParquet, FormatOpenMetrics})
+	}
+	if cc.Aggregate != "" && !slices.Contains(Levels(), cc.Aggregate) {
+		return nil, fmt.Errorf("unknown level %q, valid levels are %v", cc.Aggregate, Levels())
+	}
+	if cc.Format == FormatSQLite {
+		return newSqliteConverter(dest, cc.From)
+	}
+
+	newEncoder, ext, err := cc.encoder(dest)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := os.Stat(dest)
+	if err == nil && !s.IsDir() {
+		return nil, fmt.Errorf("dest path exists but is not a directory %q", dest)
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		err = os.Mkdir(dest, 0750)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := &failsafeSummaries{to: filepath.Join(dest, failsafeSummaryName+ext), format: cc.Format}
+	if cc.Concat || cc.Format.single() {
+		return &concatConverter{to: path.Join(dest, "surefire"+ext), once: &sync.Once{}, newEncoder: newEncoder, failsafeSummaries: summaries}, nil
+	}
+	return &separateConverter{from: cc.From, to: dest, ext: ext, newEncoder: newEncoder, names: map[string]string{}, used: map[string]string{}, failsafeSummaries: summaries}, nil
+}
+
+// encoder returns a constructor for encoders of the converters format and
+// the file extension of the files it writes into dest.
+func (cc Converter) encoder(dest string) (func(io.Writer) (encoder, error), string, error) {
+	switch cc.Format {
+	case FormatCSV, "":
+		opts, err := cc.recordOptions()
+		if err != nil {
+			return nil, "", err
+		}
+		// shared by all encoders so output files are numbered across reports
+		sidecars := newSidecars(dest)
+		return func(w io.Writer) (encoder, error) {
+			enc, err := newCsvEncoder(w, opts)
+			if err != nil {
+				return nil, err
+			}
+			enc.sidecars = sidecars
+			return enc, nil
+		}, ".csv", nil
+	case FormatJSON:
+		return func(w io.Writer) (encoder, error) {
+			return &jsonEncoder{w: w, array: cc.Concat}, nil
+		}, ".json", nil
+	case FormatNDJSON:
+		return func(w io.Writer) (encoder, error) {
+			return &ndjsonEncoder{w: w}, nil
+		}, ".ndjson", nil
+	case FormatHTML:
+		opts, err := cc.recordOptions()
+		if err != nil {
+			return nil, "", err
+		}
+		return func(w io.Writer) (encoder, error) {
+			return newHTMLEncoder(w, opts)
+		}, ".html", nil
+	case FormatXLSX:
+		opts, err := cc.recordOptions()
+		if err != nil {
+			return nil, "", err
+		}
+		return func(w io.Writer) (encoder, error) {
+			return newXLSXEncoder(w, opts)
+		}, ".xlsx", nil
+	case FormatParquet:
+		opts, err := cc.recordOptions()
+		if err != nil {
+			return nil, "", err
+		}
+		return func(w io.Writer) (encoder, error) {
+			return newParquetEncoder(w, opts, cc.RowGroupSize), nil
+		}, ".parquet", nil
+	case FormatOpenMetrics:
+		properties, err := propertyNames(cc.From, cc.filter(), cc.Properties)
+		if err != nil {
+			return nil, "", err
+		}
+		if err := validatePropertyLabels(properties); err != nil {
+			return nil, "", err
+		}
+		level := cc.Aggregate
+		if level == "" {
+			level = LevelTest
+		}
+		return func(w io.Writer) (encoder, error) {
+			return newOpenMetricsEncoder(w, level, properties), nil
+		}, ".prom", nil
+	}
+	return nil, "", fmt.Errorf("unknown format %q, valid formats are %v", cc.Format, Formats())
+}
+
+// recordOptions returns the options of the records written by tabular
+// formats. Columns are validated so conversion fails before any report is
+// converted instead of once per report.
+func (cc Converter) recordOptions() (recordOptions, error) {
+	properties, err := propertyNames(cc.From, cc.filter(), cc.Properties)
+	if err != nil {
+		return recordOptions{}, err
+	}
+	opts := recordOptions{stackTrace: cc.StackTrace, attempts: cc.Attempts, output: cc.Output, columns: cc.Columns, properties: properties, archives: hasArchives(cc.From, cc.filter())}
+	if _, err := opts.selected(); err != nil {
+		return recordOptions{}, err
+	}
+	return opts, nil
+}
+
+// encoder encodes test suites into one file. Close must be called once all
+// suites have been encoded. It does not close the underlying writer.
+type encoder interface {
+	encode(suite TestSuite) error
+	io.Closer
+}
+
+// caseEncoder is an encoder that encodes test cases one at a time so reports
+// are encoded while they are decoded.
+type caseEncoder interface {
+	encoder
+	encodeCase(suite TestSuite, c TestCase) error
+}
+
+// converter writes decoded reports. Reports are written one at a time in the
+// order of their paths followed by the Failsafe summaries.
+type converter interface {
+	write(from string, suites []TestSuite) error
+	writeSummary(s FailsafeSummary) error
+	io.Closer
+}
+
+// caseConverter is a converter that writes the test cases of reports as they
+// are decoded if its encoders are caseEncoders.
+type caseConverter interface {
+	converter
+	writeCases(from string, cases iter.Seq2[TestSuite, TestCase]) error
+}
+
+// discarder is a converter that removes the output of a report that failed
+// after its test cases have been written so no truncated file is left behind.
+type discarder interface {
+	discard(from string) error
+}
+
+// failsafeSummaries collects the Failsafe summaries and writes them into one
+// file on Close. No file is written if there are no summaries.
+type failsafeSummaries struct {
+	to        string
+	format    Format
+	summaries []FailsafeSummary
+}
+
+func (fs *failsafeSummaries) writeSummary(s FailsafeSummary) error {
+	fs.summaries = append(fs.summaries, s)
+	return nil
+}
+
+func (fs *failsafeSummaries) Close() error {
+	if fs == nil || len(fs.summaries) == 0 {
+		return nil
+	}
+	return writeFile(fs.to, func(w io.Writer) error {
+		return writeFailsafeSummaries(w, fs.format, fs.summaries)
+	})
+}
+
+type concatConverter struct {
+	to         string
+	newEncoder func(io.Writer) (encoder, error)
+	// mu guards w and enc which are shared by all reports
+	mu   sync.Mutex
+	w    io.WriteCloser
+	enc  encoder
+	once *sync.Once
+	*failsafeSummaries
+}
+
+// separateConverter writes every report into its own file in to. Files are
+// named like their report unless reports have the same name or are in an
+// archive. See name.
+type separateConverter struct {
+	from       string
+	to         string
+	ext        string
+	newEncoder func(io.Writer) (encoder, error)
+	// names holds the file name of every report by its path. It is set by
+	// name before any report is written.
+	names map[string]string
+	// mu guards used which holds the report each file is written for
+	mu   sync.Mutex
+	used map[string]string
+	*failsafeSummaries
+}
+
+// write encodes the suites into the concatenated file. It is safe to be called
+// by multiple goroutines.
+func (cc *concatConverter) write(from string, suites []TestSuite) error {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	enc, err := cc.encoder(from)
+	if err != nil {
+		return err
+	}
+	for _, suite := range suites {
+		if err := enc.encode(suite); err != nil {
+			return fileError(PhaseWrite, from, err)
+		}
+	}
+	return nil
+}
+
+// writeCases encodes the test cases into the concatenated file. It is safe to
+// be called by multiple goroutines.
+func (cc *concatConverter) writeCases(from string, cases iter.Seq2[TestSuite, TestCase]) error {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	enc, err := cc.encoder(from)
+	if err != nil {
+		return err
+	}
+	return fileError(PhaseWrite, from, encodeCases(enc, cases))
+}
+
+// encoder returns the encoder shared by all reports creating the
+// concatenated file on first use. The caller must hold the lock.
+func (cc *concatConverter) encoder(from string) (encoder, error) {
+	var err error
+	cc.once.Do(func() {
+		// declaration needed so err is closed over. w, err := ... does not work
+		var w *os.File
+		w, err = os.Create(cc.to)
+		if err != nil {
+			return
+		}
+
+		cc.w = w
+		cc.enc, err = cc.newEncoder(w)
+	})
+	if err != nil {
+		return nil, fileError(PhaseWrite, from, err)
+	}
+	if cc.enc == nil {
+		return nil, fileError(PhaseWrite, from, fmt.Errorf("failed to create %q", cc.to))
+	}
+	return cc.enc, nil
+}
+
+func (cc *concatConverter) Close() error {
+	err := cc.failsafeSummaries.Close()
+	if cc.w == nil {
+		return err
+	}
+	if cc.enc != nil {
+		if err := cc.enc.Close(); err != nil {
+			cc.w.Close()
+			return err
+		}
+	}
+	if cerr := cc.w.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (sc *separateConverter) write(from string, suites []TestSuite) error {
+	to, err := sc.create(from)
+	if err != nil {
+		return err
+	}
+	err = writeFile(to, func(w io.Writer) error {
+		enc, err := sc.newEncoder(w)
+		if err != nil {
+			return err
+		}
+		for _, suite := range suites {
+			if err := enc.encode(suite); err != nil {
+				return err
+			}
+		}
+		return enc.Close()
+	})
+	return fileError(PhaseWrite, from, err)
+}
+
+// writeCases encodes the test cases into their own file as they are decoded.
+// It is safe to be called by multiple goroutines.
+func (sc *separateConverter) writeCases(from string, cases iter.Seq2[TestSuite, TestCase]) error {
+	to, err := sc.create(from)
+	if err != nil {
+		return err
+	}
+	err = writeFile(to, func(w io.Writer) error {
+		enc, err := sc.newEncoder(w)
+		if err != nil {
+			return err
+		}
+		if err := encodeCases(enc, cases); err != nil {
+			return err
+		}
+		return enc.Close()
+	})
+	return fileError(PhaseWrite, from, err)
+}
+
+// create returns the file the report in from is converted to. It fails if
+// another report is already converted to the same file. It is safe to be
+// called by multiple goroutines.
+func (sc *separateConverter) create(from string) (string, error) {
+	to := sc.filename(from)
+	sc.mu.Lock()
+	other, ok := sc.used[to]
+	if !ok {
+		sc.used[to] = from
+	}
+	sc.mu.Unlock()
+	if ok && other != from {
+		return "", fileError(PhaseWrite, from, fmt.Errorf("%q is already converted to %q", other, to))
+	}
+	return to, nil
+}
+
+// discard removes the file of the report in from.
+func (sc *separateConverter) discard(from string) error {
+	sc.mu.Lock()
+	other := sc.used[sc.filename(from)]
+	sc.mu.Unlock()
+	if other != from {
+		// the file belongs to another report
+		return nil
+	}
+	err := os.Remove(sc.filename(from))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fileError(PhaseWrite, from, err)
+	}
+	return nil
+}
+
+// encodeCases encodes the test cases using enc which has to be a
+// caseEncoder.
+func encodeCases(enc encoder, cases iter.Seq2[TestSuite, TestCase]) error {
+	ce, ok := enc.(caseEncoder)
+	if !ok {
+		return errors.New("encoder cannot encode single test cases")
+	}
+	for suite, c := range cases {
+		if err := ce.encodeCase(suite, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFile creates the file name and writes it using write.
+func writeFile(name string, write func(io.Writer) error) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (sc *separateConverter) Close() error {
+	return sc.failsafeSummaries.Close()
+}
+
+// name names the files the reports are converted to. All files are written
+// into the same directory so the output files of test cases are found
+// relative to them. A file is named like its report with the extension of the
+// format. Reports in an archive in the converted directory are prefixed with
+// the name of the archive. Reports whose names are still the same are named
+// after their path relative to the converted directory with directories
+// separated by an underscore so CI artifacts or modules with the same
+// reports do not overwrite each other.
+func (sc *separateConverter) name(reports []reportFile) {
+	names := map[string]string{}
+	count := map[string]int{}
+	for _, rf := range reports {
+		if rf.failsafe {
+			continue
+		}
+		name := filepath.Base(rf.path)
+		if rf.archive != "" && rf.archive != sc.from {
+			name = filepath.Base(rf.archive) + "_" + name
+		}
+		names[rf.path] = name
+		count[name]++
+	}
+	for path, name := range names {
+		if rel, err := filepath.Rel(sc.from, path); err == nil && count[name] > 1 {
+			name = strings.ReplaceAll(filepath.ToSlash(rel), "/", "_")
+		}
+		sc.names[path] = strings.TrimSuffix(name, filepath.Ext(name)) + sc.ext
+	}
+}
+
+// filename returns the file the report in from is converted to.
+func (sc *separateConverter) filename(from string) string {
+	name, ok := sc.names[from]
+	if !ok {
+		name = strings.TrimSuffix(filepath.Base(from), filepath.Ext(from)) + sc.ext
+	}
+	return filepath.Join(sc.to, name)
+}
+
+// decodeFile decodes the test suites in the report rf. The output of test
+// cases is only decoded if output is true. Errors are returned as FileError.
+func decodeFile(rf reportFile, output bool) ([]TestSuite, error) {
+	r, err := rf.fsys.Open(rf.name)
+	if err != nil {
+		return nil, fileError(PhaseOpen, rf.path, err)
+	}
+	defer r.Close()
+
+	suites, err := decode(r, output)
+	return suites, fileError(PhaseDecode, rf.path, err)
+}
+
+// decodeFileCases decodes the report rf calling fn for every test case as
+// soon as it is decoded like decodeCases. Errors are returned as FileError.
+func decodeFileCases(rf reportFile, output bool, fn func(suite *TestSuite, c TestCase) error) ([]TestSuite, error) {
+	r, err := rf.fsys.Open(rf.name)
+	if err != nil {
+		return nil, fileError(PhaseOpen, rf.path, err)
+	}
+	defer r.Close()
+
+	suites, err := decodeCases(r, output, fn)
+	return suites, fileError(PhaseDecode, rf.path, err)
+}
@@ -0,0 +1,399 @@
+package surefire
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestConverter(t *testing.T) {
+	t.Run("FailsOnUnknownFormat", func(t *testing.T) {
+		var w bytes.Buffer
+		c := Converter{From: "testdata/input", Log: &w, Format: "yaml"}
+		dest := filepath.Join(t.TempDir(), "dest")
+
+		err := c.To(dest)
+
+		if err == nil {
+			t.Fatal("expected an error but got none")
+		}
+		if want := `unknown format "yaml"`; !strings.Contains(err.Error(), want) {
+			t.Fatalf("got %q but want it to contain %q", err, want)
+		}
+		if _, err := os.Stat(dest); err == nil {
+			t.Fatal("dest should not be created on an unknown format")
+		}
+	})
+}
+
+func TestConverterColumns(t *testing.T) {
+	t.Run("FailsOnUnknownColumn", func(t *testing.T) {
+		var w bytes.Buffer
+		c := Converter{From: "testdata/input", Log: &w, Columns: []string{"test", "owner"}}
+		dest := filepath.Join(t.TempDir(), "dest")
+
+		err := c.To(dest)
+
+		if err == nil {
+			t.Fatal("expected an error but got none")
+		}
+		if want := `unknown column "owner", valid columns are module, class, test, duration, suite,`; !strings.Contains(err.Error(), want) {
+			t.Fatalf("got %q but want it to contain %q", err, want)
+		}
+		if _, err := os.Stat(dest); err == nil {
+			t.Error("dest should not be created if columns are invalid")
+		}
+	})
+
+	t.Run("FailsOnOutputColumnWithoutOutput", func(t *testing.T) {
+		var w bytes.Buffer
+		c := Converter{From: "testdata/input", Log: &w, Columns: []string{"test", "stdout-file"}}
+
+		err := c.To(t.TempDir())
+
+		if err == nil {
+			t.Fatal("expected an error but got none")
+		}
+	})
+
+	t.Run("WritesSelectedColumns", func(t *testing.T) {
+		var w bytes.Buffer
+		c := Converter{From: "testdata/input", Log: &w, Concat: true, Columns: []string{"class", "test", "status"}}
+		dest := t.TempDir()
+
+		err := c.To(dest)
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		f, err := os.Open(filepath.Join(dest, "surefire.csv"))
+		if err != nil {
+			t.Fatalf("failed to open CSV due to %s", err)
+		}
+		defer f.Close()
+		records, err := csv.NewReader(f).ReadAll()
+		if err != nil {
+			t.Fatalf("failed to read CSV due to %s", err)
+		}
+		if diff := cmp.Diff([]string{"class", "test", "test status"}, records[0]); diff != "" {
+			t.Errorf("header mismatch (-want +got): \n%s", diff)
+		}
+		for _, record := range records[1:] {
+			if len(record) != 3 {
+				t.Errorf("expected 3 columns but got %v", record)
+			}
+		}
+	})
+}
+
+func TestConverterProperties(t *testing.T) {
+	t.Run("WritesMatchingProperties", func(t *testing.T) {
+		var w bytes.Buffer
+		c := Converter{From: "testdata/input", Log: &w, Concat: true, Columns: []string{"test"}, Properties: []string{"java.vm.*", "missing"}}
+		dest := t.TempDir()
+
+		err := c.To(dest)
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		f, err := os.Open(filepath.Join(dest, "surefire.csv"))
+		if err != nil {
+			t.Fatalf("failed to open CSV due to %s", err)
+		}
+		defer f.Close()
+		records, err := csv.NewReader(f).ReadAll()
+		if err != nil {
+			t.Fatalf("failed to read CSV due to %s", err)
+		}
+		want := []string{
+			"test",
+			"property java.vm.compressedOopsMode",
+			"property java.vm.info",
+			"property java.vm.name",
+			"property java.vm.specification.name",
+			"property java.vm.specification.vendor",
+			"property java.vm.specification.version",
+			"property java.vm.vendor",
+			"property java.vm.version",
+			"property missing",
+		}
+		if diff := cmp.Diff(want, records[0]); diff != "" {
+			t.Errorf("header mismatch (-want +got): \n%s", diff)
+		}
+	})
+
+	t.Run("FailsIfFormatIsNotCSV", func(t *testing.T) {
+		var w bytes.Buffer
+		c := Converter{From: "testdata/input", Log: &w, Format: FormatNDJSON, Properties: []string{"os.name"}}
+
+		err := c.To(t.TempDir())
+
+		if err == nil {
+			t.Fatal("expected an error but got none")
+		}
+	})
+}
+
+func TestConverterStrict(t *testing.T) {
+	src := t.TempDir()
+	b, err := os.ReadFile("testdata/input/TEST-org.hisp.dhis.maintenance.HardDeleteAuditTest.xml")
+	if err != nil {
+		t.Fatalf("failed to read report due to %s", err)
+	}
+	valid := filepath.Join(src, "TEST-org.hisp.dhis.maintenance.HardDeleteAuditTest.xml")
+	if err := os.WriteFile(valid, b, 0600); err != nil {
+		t.Fatalf("failed to write report due to %s", err)
+	}
+	invalid := filepath.Join(src, "TEST-invalid.xml")
+	if err := os.WriteFile(invalid, []byte("<testsuite>"), 0600); err != nil {
+		t.Fatalf("failed to write report due to %s", err)
+	}
+	sealed := filepath.Join(src, "sealed")
+	if err := os.Mkdir(sealed, 7); err != nil {
+		t.Fatalf("failed to create read-only dir %q due to %s", sealed, err)
+	}
+
+	t.Run("ReturnsAllFailures", func(t *testing.T) {
+		var w bytes.Buffer
+		c := Converter{From: src, Log: &w, Strict: true}
+		dest := t.TempDir()
+
+		err := c.To(dest)
+
+		var got FileErrors
+		if !errors.As(err, &got) {
+			t.Fatalf("expected FileErrors but got %v", err)
+		}
+		want := FileErrors{
+			{Path: sealed, Phase: PhaseWalk},
+			{Path: invalid, Phase: PhaseDecode},
+		}
+		ignoreCause := cmpopts.IgnoreFields(FileError{}, "Err")
+		less := func(a, b *FileError) bool { return a.Path < b.Path }
+		if diff := cmp.Diff(want, got, ignoreCause, cmpopts.SortSlices(less)); diff != "" {
+			t.Errorf("To() errors mismatch (-want +got): \n%s", diff)
+		}
+		var fe *FileError
+		if !errors.As(err, &fe) {
+			t.Errorf("expected errors.As to find a FileError in %v", err)
+		}
+		// other reports are still converted
+		if _, err := os.Stat(filepath.Join(dest, "TEST-org.hisp.dhis.maintenance.HardDeleteAuditTest.csv")); err != nil {
+			t.Errorf("expected report to be converted but got %s", err)
+		}
+	})
+
+	t.Run("OnlyLogsFailuresIfNotStrict", func(t *testing.T) {
+		var w bytes.Buffer
+		c := Converter{From: src, Log: &w}
+
+		err := c.To(t.TempDir())
+
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+		if !strings.Contains(w.String(), "Failed to convert") {
+			t.Errorf("expected failure to be logged instead got %q", w.String())
+		}
+	})
+}
+
+func TestConverterBrokenReport(t *testing.T) {
+	src := t.TempDir()
+	writeReport(t, src, "TEST-org.hisp.dhis.BrokenTest.xml", `<testsuite name="org.hisp.dhis.BrokenTest" time="2" tests="2" errors="0" skipped="0" failures="0">
+  <testcase name="first" classname="org.hisp.dhis.BrokenTest" time="1"/>
+  <testcase name="second" classname="org.hisp.dhis.BrokenTest" time="1">
+</testsuite>`)
+
+	tc := map[string]struct {
+		format Format
+		file   string
+	}{
+		"CSV": {
+			format: FormatCSV,
+			file:   "TEST-org.hisp.dhis.BrokenTest.csv",
+		},
+		"NDJSON": {
+			format: FormatNDJSON,
+			file:   "TEST-org.hisp.dhis.BrokenTest.ndjson",
+		},
+	}
+
+	for k, v := range tc {
+		t.Run("RemovesPartialOutputOf"+k, func(t *testing.T) {
+			var w bytes.Buffer
+			c := Converter{From: src, Log: &w, Format: v.format, Strict: true}
+			dest := t.TempDir()
+
+			err := c.To(dest)
+
+			var got FileErrors
+			if !errors.As(err, &got) {
+				t.Fatalf("expected FileErrors but got %v", err)
+			}
+			if len(got) != 1 || got[0].Phase != PhaseDecode {
+				t.Errorf("expected a decode error but got %v", got)
+			}
+			if _, err := os.Stat(filepath.Join(dest, v.file)); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("expected %q to be removed but got %v", v.file, err)
+			}
+		})
+	}
+
+	t.Run("WritesNoTestCasesOfBrokenReportIfConcatenated", func(t *testing.T) {
+		src := t.TempDir()
+		writeReport(t, src, "TEST-org.hisp.dhis.BrokenTest.xml", `<testsuite name="org.hisp.dhis.BrokenTest" time="2" tests="2" errors="0" skipped="0" failures="0">
+  <testcase name="first" classname="org.hisp.dhis.BrokenTest" time="1"/>
+  <testcase name="second" classname="org.hisp.dhis.BrokenTest" time="1"/>`)
+		writeReport(t, src, "TEST-org.hisp.dhis.ValidTest.xml", `<testsuite name="org.hisp.dhis.ValidTest" time="1" tests="1" errors="0" skipped="0" failures="0">
+  <testcase name="valid" classname="org.hisp.dhis.ValidTest" time="1"/>
+</testsuite>`)
+		var w bytes.Buffer
+		c := Converter{From: src, Log: &w, Concat: true, Columns: []string{"class", "test"}}
+		dest := t.TempDir()
+
+		err := c.To(dest)
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		got := readCSV(t, filepath.Join(dest, "surefire.csv"))
+		want := [][]string{
+			{"class", "test"},
+			{"org.hisp.dhis.ValidTest", "valid"},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("To() mismatch (-want +got): \n%s", diff)
+		}
+		if !strings.Contains(w.String(), "Failed to convert") {
+			t.Errorf("expected the broken report to be logged but got %q", w.String())
+		}
+	})
+
+	t.Run("WritesNoFileForXMLWhichIsNotAReport", func(t *testing.T) {
+		src := t.TempDir()
+		writeReport(t, src, "logback-test.xml", `<configuration><root level="INFO"/></configuration>`)
+		var w bytes.Buffer
+		c := Converter{From: src, Log: &w, Strict: true}
+		dest := t.TempDir()
+
+		err := c.To(dest)
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		files, err := os.ReadDir(dest)
+		if err != nil {
+			t.Fatalf("failed to read dest due to %s", err)
+		}
+		if len(files) != 0 {
+			t.Errorf("expected no files in dest but got %v", files)
+		}
+	})
+}
+
+func TestConverterCollidingReports(t *testing.T) {
+	src := t.TempDir()
+	report := `<testsuite name="org.hisp.dhis.ATest" time="1" tests="1" errors="0" skipped="0" failures="0">
+  <testcase name="a" classname="org.hisp.dhis.ATest" time="1"/>
+</testsuite>`
+	writeReport(t, src, "TEST-org.hisp.dhis.ATest.XML", report)
+	// converted to the same file as the extension is replaced. Reports are
+	// converted in lexical order so it comes second
+	colliding := filepath.Join(src, "TEST-org.hisp.dhis.ATest.xml")
+	writeReport(t, src, filepath.Base(colliding), report)
+	var w bytes.Buffer
+	c := Converter{From: src, Log: &w, Strict: true}
+	dest := t.TempDir()
+
+	err := c.To(dest)
+
+	var got FileErrors
+	if !errors.As(err, &got) {
+		t.Fatalf("expected FileErrors but got %v", err)
+	}
+	if len(got) != 1 || got[0].Path != colliding || got[0].Phase != PhaseWrite {
+		t.Errorf("expected a write error for %q but got %v", colliding, got)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "TEST-org.hisp.dhis.ATest.csv")); err != nil {
+		t.Errorf("expected the first report to be converted but got %s", err)
+	}
+}
+
+func TestConcatConverter(t *testing.T) {
+	newEncoder := func(w io.Writer) (encoder, error) {
+		return newCsvEncoder(w, recordOptions{})
+	}
+
+	rf, err := diskReport("testdata/input/TEST-org.hisp.dhis.maintenance.HardDeleteAuditTest.xml")
+	if err != nil {
+		t.Fatalf("failed to find report due to %s", err)
+	}
+	suites, err := decodeFile(rf, false)
+	if err != nil {
+		t.Fatalf("failed to decode report due to %s", err)
+	}
+
+	t.Run("FailsIfToCannotBeCreated", func(t *testing.T) {
+		dest := filepath.Join(t.TempDir(), "dest")
+		err := os.Mkdir(dest, 0440)
+		if err != nil {
+			t.Fatalf("failed to create dest dir for test: %s", err)
+		}
+
+		cc := &concatConverter{to: filepath.Join(dest, "surefire.csv"), once: &sync.Once{}, newEncoder: newEncoder}
+
+		err = cc.write("testdata/input/TEST-org.hisp.dhis.maintenance.HardDeleteAuditTest.xml", suites)
+		if err == nil {
+			t.Error("expected an error but got none")
+		}
+		_, err = os.Stat(filepath.Join(dest, "surefire.csv"))
+		if err == nil {
+			// if destiation does not exist os.Stat errs, if parent dir has exec
+			// bit not set it errs
+			t.Fatal("surefire.csv should not be created. expected an error but got none")
+		}
+	})
+
+	t.Run("IsSafeForConcurrentUse", func(t *testing.T) {
+		to := filepath.Join(t.TempDir(), "surefire.csv")
+		cc := &concatConverter{to: to, once: &sync.Once{}, newEncoder: newEncoder}
+
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := cc.write("TEST-org.hisp.dhis.maintenance.HardDeleteAuditTest.xml", suites); err != nil {
+					t.Errorf("expected no error but got %s", err)
+				}
+			}()
+		}
+		wg.Wait()
+		if err := cc.Close(); err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		b, err := os.ReadFile(to)
+		if err != nil {
+			t.Fatalf("failed to read %q due to %s", to, err)
+		}
+		records, err := csv.NewReader(bytes.NewReader(b)).ReadAll()
+		if err != nil {
+			t.Fatalf("expected valid CSV but got %s", err)
+		}
+		if got, want := len(records), 1+8*len(suites[0].Cases); got != want {
+			t.Errorf("expected %d records instead got %d", want, got)
+		}
+	})
+}
@@ -2,233 +2,275 @@ package surefire
 
 import (
 	"encoding/csv"
-	"encoding/xml"
-	"errors"
 	"fmt"
 	"io"
-	"io/fs"
-	"os"
-	"path"
-	"path/filepath"
+	"strconv"
 	"strings"
-	"sync"
 )
 
-type CsvConverter struct {
-	From   string
-	Concat bool
-	Log    io.Writer
-	Debug  bool
+// csvEncoder writes one CSV record per test case.
+type csvEncoder struct {
+	csv     *csv.Writer
+	opts    recordOptions
+	columns []column
+	// sidecars writes the output of test cases if opts.output is set.
+	sidecars *sidecars
 }
 
-func (cc CsvConverter) To(dest string) error {
-	s, err := os.Stat(dest)
-	if err == nil && !s.IsDir() {
-		return fmt.Errorf("dest path exists but is not a directory %q", dest)
-	}
-	if errors.Is(err, os.ErrNotExist) {
-		err = os.Mkdir(dest, 0750)
-	}
+func newCsvEncoder(w io.Writer, opts recordOptions) (*csvEncoder, error) {
+	columns, err := opts.selected()
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	var converter converter
-	if cc.Concat {
-		converter = &concatConverter{to: dest, once: &sync.Once{}}
-	} else {
-		converter = &separateConverter{to: dest}
+	c := csv.NewWriter(w)
+	if err := c.Write(header(columns)); err != nil {
+		return nil, err
 	}
-	defer converter.Close()
+	return &csvEncoder{csv: c, opts: opts, columns: columns}, nil
+}
 
-	// TODO collect errors in slice and report all of them
-	// using WalkDir as godoc of Walk declares it as being more efficient
-	err = filepath.WalkDir(cc.From, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			if cc.From == path {
-				// stop the Walk if from cannot be read
-				return fmt.Errorf("failed to walk %q: %w", cc.From, err)
-			}
-			fmt.Fprintf(cc.Log, "Failed to process %q due to %s\n", path, err)
-			return nil
-		}
-		if d.IsDir() || strings.ToLower(filepath.Ext(path)) != ".xml" {
-			return nil
+func (ce *csvEncoder) encode(suite TestSuite) error {
+	for _, c := range suite.Cases {
+		if err := ce.encodeCase(suite, c); err != nil {
+			return err
 		}
+	}
+
+	ce.csv.Flush()
+	return ce.csv.Error()
+}
 
-		err = converter.convert(path)
+func (ce *csvEncoder) encodeCase(suite TestSuite, c TestCase) error {
+	var stdout, stderr output
+	if ce.opts.output {
+		var err error
+		stdout, stderr, err = ce.sidecars.write(suite, c)
 		if err != nil {
-			fmt.Fprintf(cc.Log, "Failed to convert %q due to %s\n", path, err)
-			return nil
+			return err
 		}
-		if cc.Debug {
-			fmt.Fprintf(cc.Log, "Converted %q\n", path)
+	}
+	for _, r := range rows(suite, c, ce.opts.attempts) {
+		r.stdout, r.stderr = stdout, stderr
+		if err := ce.csv.Write(record(ce.columns, r)); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		return nil
-	})
+func (ce *csvEncoder) Close() error {
+	ce.csv.Flush()
+	return ce.csv.Error()
+}
 
-	return err
+// recordOptions control which columns are written by header and records.
+type recordOptions struct {
+	stackTrace bool
+	attempts   bool
+	// output adds columns for the files the output of test cases is written
+	// to.
+	output bool
+	// columns are the names of the columns to write in order. stackTrace and
+	// output do not add any columns if they are set.
+	columns []string
+	// properties are the names of the properties added as columns after all
+	// other columns.
+	properties []string
+	// archives adds the archive column to the default columns as reports
+	// are read from archives.
+	archives bool
 }
 
-type converter interface {
-	convert(from string) error
-	io.Closer
+// row is the test case or the attempt of a test case a record is written
+// for.
+type row struct {
+	suite   TestSuite
+	test    TestCase
+	attempt int
+	status  Status
+	// failure is empty if the test passed. Skipped tests only carry a
+	// message explaining why they were skipped.
+	failure        Result
+	stdout, stderr output
 }
 
-type concatConverter struct {
-	to string
-	// TODO
-	w    io.WriteCloser
-	csv  *csv.Writer
-	once *sync.Once
+// project returns the Maven project of the row or an empty one if it is not
+// known.
+func (r row) project() Project {
+	if r.suite.Project == nil {
+		return Project{}
+	}
+	return *r.suite.Project
 }
 
-type separateConverter struct {
-	to string
+// column is a named CSV column.
+type column struct {
+	name   string
+	header string
+	value  func(r row) string
+	// output is true for columns that need the output of test cases.
+	output bool
 }
 
-func (cc *concatConverter) convert(from string) error {
-	var err error
-	cc.once.Do(func() {
-		// declaration needed so err is closed over. w, err := ... does not work
-		var w *os.File
-		w, err = os.Create(path.Join(cc.to, "surefire.csv"))
-		if err != nil {
-			return
-		}
+// columns are all columns that can be selected by their name.
+var columns = []column{
+	{name: "module", header: "module", value: func(r row) string { return r.suite.Module() }},
+	{name: "class", header: "class", value: func(r row) string { return r.test.ClassName }},
+	{name: "test", header: "test", value: func(r row) string { return r.test.Name }},
+	{name: "duration", header: "test duration [seconds]", value: func(r row) string { return r.test.Time.String() }},
+	{name: "suite", header: "test suite", value: func(r row) string { return r.suite.Name }},
+	{name: "suite-duration", header: "test suite duration [seconds]", value: func(r row) string { return r.suite.Time.String() }},
+	{name: "suite-tests", header: "test suite tests [number]", value: func(r row) string { return r.suite.Tests.String() }},
+	{name: "suite-errors", header: "test suite errors [number]", value: func(r row) string { return r.suite.Errors.String() }},
+	{name: "suite-skipped", header: "test suite skipped [number]", value: func(r row) string { return r.suite.Skipped.String() }},
+	{name: "suite-failures", header: "test suite failures [number]", value: func(r row) string { return r.suite.Failures.String() }},
+	{name: "group-id", header: "groupId", value: func(r row) string { return r.project().GroupID }},
+	{name: "artifact-id", header: "artifactId", value: func(r row) string { return r.project().ArtifactID }},
+	{name: "module-path", header: "module path", value: func(r row) string { return r.project().Path }},
+	{name: "plugin", header: "plugin", value: func(r row) string { return string(r.suite.Plugin) }},
+	{name: "archive", header: "archive", value: func(r row) string { return r.suite.Archive }},
+	{name: "basedir", header: "basedir", value: func(r row) string { return r.suite.Basedir() }},
+	{name: "status", header: "test status", value: func(r row) string { return string(r.status) }},
+	{name: "failure-type", header: "test failure type", value: func(r row) string { return r.failure.Type }},
+	{name: "failure-message", header: "test failure message", value: func(r row) string { return r.failure.Message }},
+	{name: "reruns", header: "test reruns [number]", value: func(r row) string { return strconv.Itoa(r.test.Reruns()) }},
+	{name: "rerun-outcome", header: "test rerun outcome", value: func(r row) string { return string(r.test.RerunOutcome()) }},
+	{name: "attempt", header: "test attempt [number]", value: func(r row) string { return strconv.Itoa(r.attempt) }},
+	{name: "stack-trace", header: "test failure stack trace", value: func(r row) string { return strings.TrimSpace(r.failure.StackTrace) }},
+	{name: "stdout-file", header: "test stdout file", value: func(r row) string { return r.stdout.File }, output: true},
+	{name: "stdout-bytes", header: "test stdout [bytes]", value: func(r row) string { return strconv.Itoa(r.stdout.Bytes) }, output: true},
+	{name: "stdout-lines", header: "test stdout [lines]", value: func(r row) string { return strconv.Itoa(r.stdout.Lines) }, output: true},
+	{name: "stderr-file", header: "test stderr file", value: func(r row) string { return r.stderr.File }, output: true},
+	{name: "stderr-bytes", header: "test stderr [bytes]", value: func(r row) string { return strconv.Itoa(r.stderr.Bytes) }, output: true},
+	{name: "stderr-lines", header: "test stderr [lines]", value: func(r row) string { return strconv.Itoa(r.stderr.Lines) }, output: true},
+}
 
-		cc.w = w
-		cc.csv = csv.NewWriter(w)
-		err = cc.csv.Write(header())
-	})
-	if err != nil {
-		return err
+// Columns returns the names of all columns that can be selected.
+func Columns() []string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.name
 	}
+	return names
+}
 
-	r, err := os.Open(from)
-	if err != nil {
-		return err
+// defaultColumns returns the names of the columns written if none are
+// selected.
+func (opts recordOptions) defaultColumns() []string {
+	names := []string{
+		"module",
+		"class",
+		"test",
+		"duration",
+		"suite-duration",
+		"suite-tests",
+		"suite-errors",
+		"suite-skipped",
+		"suite-failures",
+		"basedir",
+		"status",
+		"failure-type",
+		"failure-message",
+		"reruns",
+		"rerun-outcome",
+		"plugin",
+		"group-id",
+		"artifact-id",
+		"module-path",
 	}
-	defer r.Close()
-
-	rr, err := records(r)
-	if err != nil {
-		return err
+	if opts.archives {
+		names = append(names, "archive")
 	}
-	for _, record := range rr {
-		if err := cc.csv.Write(record); err != nil {
-			return err
-		}
+	if opts.attempts {
+		names = append(names, "attempt")
 	}
-
-	cc.csv.Flush()
-	if err := cc.csv.Error(); err != nil {
-		return err
+	if opts.stackTrace {
+		names = append(names, "stack-trace")
 	}
-
-	return nil
-}
-
-func (cc *concatConverter) Close() error {
-	// TODO do I need to keep the io.Writer so I can close it?
-	if cc.csv != nil {
-		return cc.w.Close()
+	if opts.output {
+		names = append(names, "stdout-file", "stdout-bytes", "stdout-lines", "stderr-file", "stderr-bytes", "stderr-lines")
 	}
-	return nil
+	return names
 }
 
-func (sc *separateConverter) convert(from string) error {
-	r, err := os.Open(from)
-	if err != nil {
-		return err
-	}
-	defer r.Close()
-
-	w, err := os.Create(filepath.Join(sc.to, csvFilename(from)))
-	if err != nil {
-		return err
+// selected returns the columns to write.
+func (opts recordOptions) selected() ([]column, error) {
+	names := opts.columns
+	if len(names) == 0 {
+		names = opts.defaultColumns()
 	}
-	defer w.Close()
 
-	c := csv.NewWriter(w)
-	if err := c.Write(header()); err != nil {
-		return err
-	}
-
-	rr, err := records(r)
-	if err != nil {
-		return err
-	}
-	for _, record := range rr {
-		if err := c.Write(record); err != nil {
-			return err
+	selected := make([]column, 0, len(names))
+	for _, name := range names {
+		c, ok := lookupColumn(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q, valid columns are %s", name, strings.Join(Columns(), ", "))
 		}
+		if c.output && !opts.output {
+			return nil, fmt.Errorf("column %q needs the output of tests to be written", name)
+		}
+		selected = append(selected, c)
 	}
-
-	c.Flush()
-	if err := c.Error(); err != nil {
-		return err
+	for _, name := range opts.properties {
+		selected = append(selected, propertyColumn(name))
 	}
+	return selected, nil
+}
 
-	return nil
+func propertyColumn(name string) column {
+	return column{
+		name:   "property " + name,
+		header: "property " + name,
+		value: func(r row) string {
+			v, _ := r.suite.Property(name)
+			return v
+		},
+	}
 }
 
-func (sc *separateConverter) Close() error {
-	return nil
+func lookupColumn(name string) (column, bool) {
+	for _, c := range columns {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return column{}, false
 }
 
-func csvFilename(file string) string {
-	fn := filepath.Base(file)
-	return strings.TrimSuffix(fn, filepath.Ext(fn)) + ".csv"
+func header(columns []column) []string {
+	h := make([]string, len(columns))
+	for i, c := range columns {
+		h[i] = c.header
+	}
+	return h
 }
 
-func header() []string {
-	return []string{
-		"module",
-		"class",
-		"test",
-		"test duration [seconds]",
-		"test suite duration [seconds]",
-		"test suite tests [number]",
-		"test suite errors [number]",
-		"test suite skipped [number]",
-		"test suite failures [number]",
-		"basedir",
+func record(columns []column, r row) []string {
+	record := make([]string, len(columns))
+	for i, c := range columns {
+		record[i] = c.value(r)
 	}
+	return record
 }
 
-func records(r io.Reader) ([][]string, error) {
-	var suite TestSuite
-	err := xml.NewDecoder(r).Decode(&suite)
-	if err != nil {
-		return nil, err
+// rows returns the rows of test case c of the suite. A test case has one row
+// or one row per attempt if attempts is set.
+func rows(suite TestSuite, c TestCase, attempts bool) []row {
+	if !attempts {
+		return []row{newRow(suite, c, 1, Attempt{Status: c.Status(), Result: c.Result()})}
 	}
 
-	var basedir string
-	var module string
-	for _, v := range suite.Properties.Properties {
-		if v.Name == "basedir" {
-			basedir = v.Value
-			module = filepath.Base(v.Value)
-		}
+	var rows []row
+	for i, a := range c.Attempts() {
+		rows = append(rows, newRow(suite, c, i+1, a))
 	}
+	return rows
+}
 
-	var records [][]string
-	for _, c := range suite.Cases {
-		records = append(records, []string{
-			module,
-			c.ClassName,
-			c.Name,
-			c.Time,
-			suite.Time,
-			suite.Tests,
-			suite.Errors,
-			suite.Skipped,
-			suite.Failures,
-			basedir,
-		})
-	}
-
-	return records, nil
+func newRow(suite TestSuite, c TestCase, n int, a Attempt) row {
+	r := row{suite: suite, test: c, attempt: n, status: a.Status}
+	if a.Result != nil {
+		r.failure = *a.Result
+	}
+	return r
 }
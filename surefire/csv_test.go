@@ -2,13 +2,17 @@ package surefire
 
 import (
 	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
+	"runtime"
 	"strings"
-	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -17,6 +21,7 @@ import (
 func TestConvert(t *testing.T) {
 	tc := map[string]struct {
 		input string
+		opts  recordOptions
 		want  [][]string
 		err   bool
 	}{
@@ -44,9 +49,213 @@ func TestConvert(t *testing.T) {
 					"1",
 					"0",
 					"/home/runner/work/dhis2-core/dhis2-core/dhis-2/dhis-services/dhis-service-administration",
+					"skipped",
+					"",
+					"",
+					"0",
+					"",
+					"",
+					"",
+					"",
+					"",
 				},
 			},
 		},
+		"ReportWithSelectedColumns": {
+			input: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="org.hisp.dhis.maintenance.HardDeleteAuditTest" time="0.003" tests="1" errors="0" skipped="0" failures="0">
+  <properties>
+    <property name="basedir" value="/home/runner/work/dhis2-core/dhis2-core/dhis-2/dhis-services/dhis-service-administration"/>
+  </properties>
+  <testcase name="testHardDelete" classname="org.hisp.dhis.maintenance.HardDeleteAuditTest" time="0.001"/>
+</testsuite>`,
+			opts: recordOptions{stackTrace: true, columns: []string{"status", "suite", "test", "duration"}},
+			want: [][]string{
+				{
+					"passed",
+					"org.hisp.dhis.maintenance.HardDeleteAuditTest",
+					"testHardDelete",
+					"0.001",
+				},
+			},
+		},
+		"ReportWithProperties": {
+			input: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="org.hisp.dhis.maintenance.HardDeleteAuditTest" time="0.003" tests="1" errors="0" skipped="0" failures="0">
+  <properties>
+    <property name="java.version" value="11.0.13"/>
+    <property name="os.name" value="Linux"/>
+  </properties>
+  <testcase name="testHardDelete" classname="org.hisp.dhis.maintenance.HardDeleteAuditTest" time="0.001"/>
+</testsuite>`,
+			opts: recordOptions{columns: []string{"test"}, properties: []string{"os.name", "user.timezone", "java.version"}},
+			want: [][]string{
+				{
+					"testHardDelete",
+					"Linux",
+					"",
+					"11.0.13",
+				},
+			},
+		},
+		"ReportWithUnknownColumn": {
+			input: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="org.hisp.dhis.maintenance.HardDeleteAuditTest" time="0.003" tests="0" errors="0" skipped="0" failures="0"/>`,
+			opts: recordOptions{columns: []string{"test", "owner"}},
+			err:  true,
+		},
+		"ReportWithSuiteOutput": {
+			input: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="org.hisp.dhis.maintenance.HardDeleteAuditTest" time="0.003" tests="1" errors="0" skipped="0" failures="0">
+  <properties>
+    <property name="basedir" value="/home/runner/work/dhis2-core/dhis2-core/dhis-2/dhis-services/dhis-service-administration"/>
+  </properties>
+  <system-out><![CDATA[<testcase name="ignored" classname="ignored" time="1"/>]]></system-out>
+  <testcase name="testHardDelete" classname="org.hisp.dhis.maintenance.HardDeleteAuditTest" time="0.001">
+    <system-out><![CDATA[* INFO  15:32:37,771 hard delete]]></system-out>
+    <system-err><![CDATA[* WARN  15:32:37,772 <testcase/>]]></system-err>
+  </testcase>
+  <system-err><![CDATA[* WARN  15:32:37,773 done]]></system-err>
+</testsuite>`,
+			want: [][]string{
+				{
+					"dhis-service-administration",
+					"org.hisp.dhis.maintenance.HardDeleteAuditTest",
+					"testHardDelete",
+					"0.001",
+					"0.003",
+					"1",
+					"0",
+					"0",
+					"0",
+					"/home/runner/work/dhis2-core/dhis2-core/dhis-2/dhis-services/dhis-service-administration",
+					"passed",
+					"",
+					"",
+					"0",
+					"",
+					"",
+					"",
+					"",
+					"",
+				},
+			},
+		},
+		"ReportWithTestSuitesRoot": {
+			input: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="merged" time="3.5" tests="3" errors="0" skipped="0" failures="0">
+  <properties>
+    <property name="basedir" value="/ignored"/>
+  </properties>
+  <testsuite name="org.hisp.dhis.maintenance.HardDeleteAuditTest" time="1.5" tests="1" errors="0" skipped="0" failures="0">
+    <properties>
+      <property name="basedir" value="/home/runner/work/dhis2-core/dhis-2/dhis-services/dhis-service-administration"/>
+    </properties>
+    <testcase name="testHardDelete" classname="org.hisp.dhis.maintenance.HardDeleteAuditTest" time="1.2"/>
+  </testsuite>
+  <testsuite name="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="2" tests="2" errors="0" skipped="0" failures="0">
+    <properties>
+      <property name="basedir" value="/home/runner/work/dhis2-core/dhis-2/dhis-services/dhis-service-analytics"/>
+    </properties>
+    <testcase name="testMappingAggregation" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="0.5"/>
+    <testsuite name="org.hisp.dhis.analytics.data.AnalyticsServiceTest$Nested" time="1" tests="1" errors="0" skipped="0" failures="0">
+      <testcase name="testNested" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest$Nested" time="0.9"/>
+    </testsuite>
+    <testcase name="testSetAggregation" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="0.4"/>
+  </testsuite>
+</testsuites>`,
+			want: [][]string{
+				{
+					"dhis-service-administration",
+					"org.hisp.dhis.maintenance.HardDeleteAuditTest",
+					"testHardDelete",
+					"1.2",
+					"1.5",
+					"1",
+					"0",
+					"0",
+					"0",
+					"/home/runner/work/dhis2-core/dhis-2/dhis-services/dhis-service-administration",
+					"passed",
+					"",
+					"",
+					"0",
+					"",
+					"",
+					"",
+					"",
+					"",
+				},
+				{
+					"dhis-service-analytics",
+					"org.hisp.dhis.analytics.data.AnalyticsServiceTest",
+					"testMappingAggregation",
+					"0.5",
+					"2",
+					"2",
+					"0",
+					"0",
+					"0",
+					"/home/runner/work/dhis2-core/dhis-2/dhis-services/dhis-service-analytics",
+					"passed",
+					"",
+					"",
+					"0",
+					"",
+					"",
+					"",
+					"",
+					"",
+				},
+				{
+					"",
+					"org.hisp.dhis.analytics.data.AnalyticsServiceTest$Nested",
+					"testNested",
+					"0.9",
+					"1",
+					"1",
+					"0",
+					"0",
+					"0",
+					"",
+					"passed",
+					"",
+					"",
+					"0",
+					"",
+					"",
+					"",
+					"",
+					"",
+				},
+				{
+					"dhis-service-analytics",
+					"org.hisp.dhis.analytics.data.AnalyticsServiceTest",
+					"testSetAggregation",
+					"0.4",
+					"2",
+					"2",
+					"0",
+					"0",
+					"0",
+					"/home/runner/work/dhis2-core/dhis-2/dhis-services/dhis-service-analytics",
+					"passed",
+					"",
+					"",
+					"0",
+					"",
+					"",
+					"",
+					"",
+					"",
+				},
+			},
+		},
+		"ReportWithEmptyTestSuitesRoot": {
+			input: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites/>`,
+			want: nil,
+		},
 		"ReportWithMultipleTests": {
 			input: `<?xml version="1.0" encoding="UTF-8"?>
 <testsuite xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:noNamespaceSchemaLocation="https://maven.apache.org/surefire/maven-surefire-plugin/xsd/surefire-test-report-3.0.xsd" version="3.0" name="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="171.217" tests="4" errors="1" skipped="0" failures="1">
@@ -87,6 +296,15 @@ func TestConvert(t *testing.T) {
 					"0",
 					"1",
 					"/home/runner/work/dhis2-core/dhis2-core/dhis-2/dhis-services/dhis-service-analytics",
+					"passed",
+					"",
+					"",
+					"0",
+					"",
+					"",
+					"",
+					"",
+					"",
 				},
 				{
 					"dhis-service-analytics",
@@ -99,6 +317,15 @@ func TestConvert(t *testing.T) {
 					"0",
 					"1",
 					"/home/runner/work/dhis2-core/dhis2-core/dhis-2/dhis-services/dhis-service-analytics",
+					"passed",
+					"",
+					"",
+					"0",
+					"",
+					"",
+					"",
+					"",
+					"",
 				},
 				{
 					"dhis-service-analytics",
@@ -111,6 +338,15 @@ func TestConvert(t *testing.T) {
 					"0",
 					"1",
 					"/home/runner/work/dhis2-core/dhis2-core/dhis-2/dhis-services/dhis-service-analytics",
+					"passed",
+					"",
+					"",
+					"0",
+					"",
+					"",
+					"",
+					"",
+					"",
 				},
 				{
 					"dhis-service-analytics",
@@ -123,6 +359,15 @@ func TestConvert(t *testing.T) {
 					"0",
 					"1",
 					"/home/runner/work/dhis2-core/dhis2-core/dhis-2/dhis-services/dhis-service-analytics",
+					"passed",
+					"",
+					"",
+					"0",
+					"",
+					"",
+					"",
+					"",
+					"",
 				},
 			},
 		},
@@ -145,6 +390,15 @@ func TestConvert(t *testing.T) {
 					"1",
 					"0",
 					"",
+					"skipped",
+					"",
+					"",
+					"0",
+					"",
+					"",
+					"",
+					"",
+					"",
 				},
 			},
 		},
@@ -171,6 +425,15 @@ func TestConvert(t *testing.T) {
 					"1",
 					"0",
 					"",
+					"skipped",
+					"",
+					"",
+					"0",
+					"",
+					"",
+					"",
+					"",
+					"",
 				},
 			},
 		},
@@ -198,6 +461,298 @@ func TestConvert(t *testing.T) {
 					"1",
 					"0",
 					"",
+					"skipped",
+					"",
+					"",
+					"0",
+					"",
+					"",
+					"",
+					"",
+					"",
+				},
+			},
+		},
+		"ReportWithFailedAndErroredTestCases": {
+			input: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:noNamespaceSchemaLocation="https://maven.apache.org/surefire/maven-surefire-plugin/xsd/surefire-test-report-3.0.xsd" version="3.0" name="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="2.5" tests="3" errors="1" skipped="0" failures="1">
+  <testcase name="testMappingAggregation" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="1.1">
+    <failure message="expected: &lt;1&gt; but was: &lt;2&gt;" type="org.opentest4j.AssertionFailedError"><![CDATA[org.opentest4j.AssertionFailedError: expected: <1> but was: <2>
+	at org.hisp.dhis.analytics.data.AnalyticsServiceTest.testMappingAggregation(AnalyticsServiceTest.java:42)
+]]></failure>
+  </testcase>
+  <testcase name="testGridAggregation" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="1.2">
+    <error message="boom" type="java.lang.NullPointerException">java.lang.NullPointerException: boom</error>
+  </testcase>
+  <testcase name="testSetAggregation" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="0.2"/>
+</testsuite>`,
+			opts: recordOptions{stackTrace: true},
+			want: [][]string{
+				{
+					"",
+					"org.hisp.dhis.analytics.data.AnalyticsServiceTest",
+					"testMappingAggregation",
+					"1.1",
+					"2.5",
+					"3",
+					"1",
+					"0",
+					"1",
+					"",
+					"failed",
+					"org.opentest4j.AssertionFailedError",
+					"expected: <1> but was: <2>",
+					"0",
+					"",
+					"",
+					"",
+					"",
+					"",
+					"org.opentest4j.AssertionFailedError: expected: <1> but was: <2>\n\tat org.hisp.dhis.analytics.data.AnalyticsServiceTest.testMappingAggregation(AnalyticsServiceTest.java:42)",
+				},
+				{
+					"",
+					"org.hisp.dhis.analytics.data.AnalyticsServiceTest",
+					"testGridAggregation",
+					"1.2",
+					"2.5",
+					"3",
+					"1",
+					"0",
+					"1",
+					"",
+					"errored",
+					"java.lang.NullPointerException",
+					"boom",
+					"0",
+					"",
+					"",
+					"",
+					"",
+					"",
+					"java.lang.NullPointerException: boom",
+				},
+				{
+					"",
+					"org.hisp.dhis.analytics.data.AnalyticsServiceTest",
+					"testSetAggregation",
+					"0.2",
+					"2.5",
+					"3",
+					"1",
+					"0",
+					"1",
+					"",
+					"passed",
+					"",
+					"",
+					"0",
+					"",
+					"",
+					"",
+					"",
+					"",
+					"",
+				},
+			},
+		},
+		"ReportWithReruns": {
+			input: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:noNamespaceSchemaLocation="https://maven.apache.org/surefire/maven-surefire-plugin/xsd/surefire-test-report-3.0.xsd" version="3.0" name="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="2.5" tests="2" errors="0" skipped="0" failures="1">
+  <testcase name="testMappingAggregation" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="1.1">
+    <flakyFailure message="expected: &lt;1&gt; but was: &lt;2&gt;" type="org.opentest4j.AssertionFailedError">
+      <stackTrace>org.opentest4j.AssertionFailedError: expected: &lt;1&gt; but was: &lt;2&gt;</stackTrace>
+    </flakyFailure>
+  </testcase>
+  <testcase name="testGridAggregation" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="1.2">
+    <failure message="timeout" type="java.util.concurrent.TimeoutException">java.util.concurrent.TimeoutException: timeout</failure>
+    <rerunFailure message="timeout" type="java.util.concurrent.TimeoutException">
+      <stackTrace>java.util.concurrent.TimeoutException: timeout</stackTrace>
+    </rerunFailure>
+    <rerunError message="boom" type="java.lang.NullPointerException">
+      <stackTrace>java.lang.NullPointerException: boom</stackTrace>
+    </rerunError>
+  </testcase>
+</testsuite>`,
+			want: [][]string{
+				{
+					"",
+					"org.hisp.dhis.analytics.data.AnalyticsServiceTest",
+					"testMappingAggregation",
+					"1.1",
+					"2.5",
+					"2",
+					"0",
+					"0",
+					"1",
+					"",
+					"passed",
+					"",
+					"",
+					"1",
+					"flaky",
+					"",
+					"",
+					"",
+					"",
+				},
+				{
+					"",
+					"org.hisp.dhis.analytics.data.AnalyticsServiceTest",
+					"testGridAggregation",
+					"1.2",
+					"2.5",
+					"2",
+					"0",
+					"0",
+					"1",
+					"",
+					"failed",
+					"java.util.concurrent.TimeoutException",
+					"timeout",
+					"2",
+					"failed-after-rerun",
+					"",
+					"",
+					"",
+					"",
+				},
+			},
+		},
+		"ReportWithRerunsWritesOneRowPerAttempt": {
+			input: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:noNamespaceSchemaLocation="https://maven.apache.org/surefire/maven-surefire-plugin/xsd/surefire-test-report-3.0.xsd" version="3.0" name="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="2.5" tests="2" errors="0" skipped="0" failures="1">
+  <testcase name="testMappingAggregation" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="1.1">
+    <flakyFailure message="expected: &lt;1&gt; but was: &lt;2&gt;" type="org.opentest4j.AssertionFailedError">
+      <stackTrace>org.opentest4j.AssertionFailedError: expected: &lt;1&gt; but was: &lt;2&gt;</stackTrace>
+    </flakyFailure>
+  </testcase>
+  <testcase name="testGridAggregation" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="1.2">
+    <failure message="timeout" type="java.util.concurrent.TimeoutException">java.util.concurrent.TimeoutException: timeout</failure>
+    <rerunFailure message="timeout" type="java.util.concurrent.TimeoutException">
+      <stackTrace>java.util.concurrent.TimeoutException: timeout</stackTrace>
+    </rerunFailure>
+    <rerunError message="boom" type="java.lang.NullPointerException">
+      <stackTrace>java.lang.NullPointerException: boom</stackTrace>
+    </rerunError>
+  </testcase>
+</testsuite>`,
+			opts: recordOptions{attempts: true, stackTrace: true},
+			want: [][]string{
+				{
+					"",
+					"org.hisp.dhis.analytics.data.AnalyticsServiceTest",
+					"testMappingAggregation",
+					"1.1",
+					"2.5",
+					"2",
+					"0",
+					"0",
+					"1",
+					"",
+					"failed",
+					"org.opentest4j.AssertionFailedError",
+					"expected: <1> but was: <2>",
+					"1",
+					"flaky",
+					"",
+					"",
+					"",
+					"",
+					"1",
+					"org.opentest4j.AssertionFailedError: expected: <1> but was: <2>",
+				},
+				{
+					"",
+					"org.hisp.dhis.analytics.data.AnalyticsServiceTest",
+					"testMappingAggregation",
+					"1.1",
+					"2.5",
+					"2",
+					"0",
+					"0",
+					"1",
+					"",
+					"passed",
+					"",
+					"",
+					"1",
+					"flaky",
+					"",
+					"",
+					"",
+					"",
+					"2",
+					"",
+				},
+				{
+					"",
+					"org.hisp.dhis.analytics.data.AnalyticsServiceTest",
+					"testGridAggregation",
+					"1.2",
+					"2.5",
+					"2",
+					"0",
+					"0",
+					"1",
+					"",
+					"failed",
+					"java.util.concurrent.TimeoutException",
+					"timeout",
+					"2",
+					"failed-after-rerun",
+					"",
+					"",
+					"",
+					"",
+					"1",
+					"java.util.concurrent.TimeoutException: timeout",
+				},
+				{
+					"",
+					"org.hisp.dhis.analytics.data.AnalyticsServiceTest",
+					"testGridAggregation",
+					"1.2",
+					"2.5",
+					"2",
+					"0",
+					"0",
+					"1",
+					"",
+					"failed",
+					"java.util.concurrent.TimeoutException",
+					"timeout",
+					"2",
+					"failed-after-rerun",
+					"",
+					"",
+					"",
+					"",
+					"2",
+					"java.util.concurrent.TimeoutException: timeout",
+				},
+				{
+					"",
+					"org.hisp.dhis.analytics.data.AnalyticsServiceTest",
+					"testGridAggregation",
+					"1.2",
+					"2.5",
+					"2",
+					"0",
+					"0",
+					"1",
+					"",
+					"errored",
+					"java.lang.NullPointerException",
+					"boom",
+					"2",
+					"failed-after-rerun",
+					"",
+					"",
+					"",
+					"",
+					"3",
+					"java.lang.NullPointerException: boom",
 				},
 			},
 		},
@@ -212,16 +767,33 @@ func TestConvert(t *testing.T) {
 
 	for k, v := range tc {
 		t.Run(k, func(t *testing.T) {
-			r := strings.NewReader(v.input)
-
-			got, err := records(r)
+			var b bytes.Buffer
+			enc, err := newCsvEncoder(&b, v.opts)
+			if err == nil {
+				_, err = decodeCases(strings.NewReader(v.input), false, func(suite *TestSuite, c TestCase) error {
+					return enc.encodeCase(*suite, c)
+				})
+			}
 			if v.err && err == nil {
 				t.Fatal("expected an error but got none")
 			}
 			if !v.err && err != nil {
 				t.Fatalf("expected no error but got: %s", err)
 			}
+			if err != nil {
+				return
+			}
+			if err := enc.Close(); err != nil {
+				t.Fatalf("expected no error but got: %s", err)
+			}
 
+			records, err := csv.NewReader(&b).ReadAll()
+			if err != nil {
+				t.Fatalf("failed to read CSV due to %s", err)
+			}
+			// skip the header
+			var got [][]string
+			got = append(got, records[1:]...)
 			if diff := cmp.Diff(v.want, got); diff != "" {
 				t.Errorf("convert() mismatch (-want +got): \n%s", diff)
 			}
@@ -229,11 +801,105 @@ func TestConvert(t *testing.T) {
 	}
 }
 
+// BenchmarkRecords converts reports with a growing number of test cases each
+// carrying a large system-out into their own files. The peak heap in use
+// should stay flat as the system-out is skipped and test cases are streamed
+// from the decoder to the encoder instead of being kept in memory.
+func BenchmarkRecords(b *testing.B) {
+	for _, cases := range []int{10, 100, 1000} {
+		for _, systemOut := range []int{4 << 10, 64 << 10} {
+			b.Run(fmt.Sprintf("cases=%d/system-out=%dKiB", cases, systemOut>>10), func(b *testing.B) {
+				src := b.TempDir()
+				f, err := os.Create(filepath.Join(src, "TEST-org.hisp.dhis.analytics.data.AnalyticsServiceTest.xml"))
+				if err != nil {
+					b.Fatalf("failed to create report due to %s", err)
+				}
+				if _, err := io.Copy(f, report(cases, systemOut)); err != nil {
+					b.Fatalf("failed to write report due to %s", err)
+				}
+				if err := f.Close(); err != nil {
+					b.Fatalf("failed to write report due to %s", err)
+				}
+				c := Converter{From: src, Log: io.Discard, Strict: true}
+				dest := b.TempDir()
+				b.ReportAllocs()
+				b.SetBytes(int64(cases * systemOut))
+
+				runtime.GC()
+				var m runtime.MemStats
+				runtime.ReadMemStats(&m)
+				base := m.HeapInuse
+				peak := base
+				done := make(chan struct{})
+				sampled := make(chan struct{})
+				go func() {
+					defer close(sampled)
+					ticker := time.NewTicker(time.Millisecond)
+					defer ticker.Stop()
+					var m runtime.MemStats
+					for {
+						select {
+						case <-done:
+							return
+						case <-ticker.C:
+							runtime.ReadMemStats(&m)
+							peak = max(peak, m.HeapInuse)
+						}
+					}
+				}()
+				b.ResetTimer()
+				for range b.N {
+					if err := c.To(dest); err != nil {
+						b.Fatalf("expected no error but got %s", err)
+					}
+				}
+				b.StopTimer()
+				close(done)
+				<-sampled
+				b.ReportMetric(float64(peak-base), "peak-heap-B")
+			})
+		}
+	}
+}
+
+// report returns a report with the given number of test cases each with a
+// system-out of systemOut bytes. The report is generated while it is read so
+// it can be written to a file without holding all of it in memory.
+func report(cases, systemOut int) io.Reader {
+	readers := []io.Reader{strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="171.217" tests="4" errors="0" skipped="0" failures="0">
+  <properties>
+    <property name="basedir" value="/home/runner/work/dhis2-core/dhis2-core/dhis-2/dhis-services/dhis-service-analytics"/>
+  </properties>
+`)}
+	for i := range cases {
+		readers = append(readers,
+			strings.NewReader(fmt.Sprintf(`  <testcase name="test%d" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="0.1">
+    <system-out><![CDATA[`, i)),
+			io.LimitReader(logLine{}, int64(systemOut)),
+			strings.NewReader("]]></system-out>\n  </testcase>\n"),
+		)
+	}
+	readers = append(readers, strings.NewReader("</testsuite>\n"))
+	return io.MultiReader(readers...)
+}
+
+// logLine is an endless log line.
+type logLine struct{}
+
+func (logLine) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = '*'
+	}
+	return len(p), nil
+}
+
 func TestCsvConverter(t *testing.T) {
 	tc := map[string]struct {
-		input  string
-		concat bool
-		want   string
+		input   string
+		concat  bool
+		workers int
+		want    string
 	}{
 		"OneCSVFilePerXML": {
 			input:  "testdata/input",
@@ -245,12 +911,24 @@ func TestCsvConverter(t *testing.T) {
 			concat: true,
 			want:   "testdata/expected/concat",
 		},
+		"ConcatenatedCSVFileWithOneWorker": {
+			input:   "testdata/input",
+			concat:  true,
+			workers: 1,
+			want:    "testdata/expected/concat",
+		},
+		"ConcatenatedCSVFileWithMoreWorkersThanReports": {
+			input:   "testdata/input",
+			concat:  true,
+			workers: 32,
+			want:    "testdata/expected/concat",
+		},
 	}
 
 	for k, v := range tc {
 		t.Run(k, func(t *testing.T) {
 			var w bytes.Buffer
-			c := CsvConverter{From: v.input, Concat: v.concat, Log: &w}
+			c := CsvConverter{From: v.input, Concat: v.concat, Workers: v.workers, Log: &w}
 
 			dest := t.TempDir()
 
@@ -409,52 +1087,3 @@ func TestCsvConverter(t *testing.T) {
 		}
 	})
 }
-
-func TestConcatConverter(t *testing.T) {
-	t.Run("FailsIfToCannotBeCreated", func(t *testing.T) {
-		dest := filepath.Join(t.TempDir(), "dest")
-		err := os.Mkdir(dest, 0440)
-		if err != nil {
-			t.Fatalf("failed to create dest dir for test: %s", err)
-		}
-
-		cc := &concatConverter{to: dest, once: &sync.Once{}}
-
-		err = cc.convert("testdata/input/TEST-org.hisp.dhis.maintenance.HardDeleteAuditTest.xml")
-		if err == nil {
-			t.Error("expected an error but got none")
-		}
-		_, err = os.Stat(filepath.Join(dest, "surefire.csv"))
-		if err == nil {
-			// if destiation does not exist os.Stat errs, if parent dir has exec
-			// bit not set it errs
-			t.Fatal("surefire.csv should not be created. expected an error but got none")
-		}
-	})
-
-	t.Run("FailsIfFromCannotBeRead", func(t *testing.T) {
-		src := filepath.Join(t.TempDir(), "src")
-		err := os.Mkdir(src, 0750)
-		if err != nil {
-			t.Fatalf("failed to create src dir for test: %s", err)
-		}
-		f := filepath.Join(src, "non-readable")
-		err = os.WriteFile(f, []byte("data"), 0004)
-		if err != nil {
-			t.Fatalf("failed to create non-readable file for test: %s", err)
-		}
-
-		cc := &concatConverter{to: t.TempDir(), once: &sync.Once{}}
-
-		err = cc.convert(f)
-		if err == nil {
-			t.Error("expected an error but got none")
-		}
-		// the to file will currently still be created and not removed if the
-		// only file to convert fails conversion
-		_, err = os.Stat(filepath.Join(cc.to, "surefire.csv"))
-		if err != nil {
-			t.Fatalf("surefire.csv should have been created. expected no error but got %s", err)
-		}
-	})
-}
@@ -0,0 +1,342 @@
+package surefire
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Differ compares the Maven Surefire XML reports found in Base with the ones
+// found in Head. Test cases are matched by module, class and name.
+type Differ struct {
+	Base string
+	Head string
+	// Include, Exclude and FollowSymlinks select the reports in Base and
+	// Head like they do in Converter.
+	Include        []string
+	Exclude        []string
+	FollowSymlinks bool
+	Log            io.Writer
+	Debug          bool
+	Thresholds     Thresholds
+	// FailOnRemoved counts test cases in base that are not in head as
+	// regressions so tests cannot be deleted unnoticed.
+	FailOnRemoved bool
+}
+
+// Thresholds define when a test case that got slower is a regression. A test
+// case is a regression if its duration increased by at least Absolute seconds
+// and by at least Percent percent. A zero threshold is ignored. Durations are
+// not compared at all if both thresholds are zero.
+type Thresholds struct {
+	Absolute float64
+	Percent  float64
+}
+
+// Change describes how a test case changed from base to head.
+type Change string
+
+const (
+	// ChangeNone means the test case is in base and head and did not change
+	// from passing to failing or vice versa.
+	ChangeNone         Change = ""
+	ChangeAdded        Change = "added"
+	ChangeRemoved      Change = "removed"
+	ChangeNewlyFailing Change = "newly-failing"
+	ChangeFixed        Change = "fixed"
+)
+
+// Diff holds all test cases found in base or head ordered by module, class
+// and name.
+type Diff struct {
+	Cases         []CaseDiff
+	Thresholds    Thresholds
+	FailOnRemoved bool
+}
+
+// CaseDiff compares a test case in base with the one in head. The status of a
+// test case that is not in base or head is empty.
+type CaseDiff struct {
+	Module     string
+	Class      string
+	Name       string
+	Change     Change
+	BaseStatus Status
+	HeadStatus Status
+	// BaseTime and HeadTime are the durations in seconds.
+	BaseTime float64
+	HeadTime float64
+}
+
+type caseKey struct {
+	module, class, name string
+}
+
+type caseResult struct {
+	status Status
+	time   float64
+}
+
+func (d Differ) Diff() (Diff, error) {
+	if err := d.filter().validate(); err != nil {
+		return Diff{}, err
+	}
+	base, err := d.load(d.Base)
+	if err != nil {
+		return Diff{}, err
+	}
+	head, err := d.load(d.Head)
+	if err != nil {
+		return Diff{}, err
+	}
+
+	diff := Diff{Thresholds: d.Thresholds, FailOnRemoved: d.FailOnRemoved}
+	for k, b := range base {
+		c := CaseDiff{Module: k.module, Class: k.class, Name: k.name, BaseStatus: b.status, BaseTime: b.time}
+		h, ok := head[k]
+		if ok {
+			c.HeadStatus = h.status
+			c.HeadTime = h.time
+		}
+		c.Change = change(c, ok)
+		diff.Cases = append(diff.Cases, c)
+	}
+	for k, h := range head {
+		if _, ok := base[k]; ok {
+			continue
+		}
+		diff.Cases = append(diff.Cases, CaseDiff{Module: k.module, Class: k.class, Name: k.name, Change: ChangeAdded, HeadStatus: h.status, HeadTime: h.time})
+	}
+	sort.Slice(diff.Cases, func(i, j int) bool {
+		a, b := diff.Cases[i], diff.Cases[j]
+		if a.Module != b.Module {
+			return a.Module < b.Module
+		}
+		if a.Class != b.Class {
+			return a.Class < b.Class
+		}
+		return a.Name < b.Name
+	})
+
+	return diff, nil
+}
+
+func change(c CaseDiff, inHead bool) Change {
+	switch {
+	case !inHead:
+		return ChangeRemoved
+	case !failing(c.BaseStatus) && failing(c.HeadStatus):
+		return ChangeNewlyFailing
+	case failing(c.BaseStatus) && !failing(c.HeadStatus):
+		return ChangeFixed
+	}
+	return ChangeNone
+}
+
+func failing(s Status) bool {
+	return s == StatusFailed || s == StatusErrored
+}
+
+// load returns all test cases found in dir. Durations of test cases that are
+// reported more than once are summed up and they are failing if any of them
+// failed.
+func (d Differ) load(dir string) (map[caseKey]caseResult, error) {
+	cases := map[caseKey]caseResult{}
+	_, err := walk(dir, d.Log, d.Debug, walkOptions{filter: d.filter()}, func(path string, suites []TestSuite) error {
+		for _, suite := range suites {
+			module := suite.Module()
+			for _, c := range suite.Cases {
+				k := caseKey{module: module, class: c.ClassName, name: c.Name}
+				r, ok := cases[k]
+				if !ok || (!failing(r.status) && failing(c.Status())) {
+					r.status = c.Status()
+				}
+				r.time += c.Time.Value
+				cases[k] = r
+			}
+		}
+		return nil
+	})
+	return cases, err
+}
+
+// filter returns the filter selecting the reports to compare.
+func (d Differ) filter() fileFilter {
+	return fileFilter{include: d.Include, exclude: d.Exclude, followSymlinks: d.FollowSymlinks}
+}
+
+// Delta returns by how many seconds the test case got slower.
+func (c CaseDiff) Delta() float64 {
+	return c.HeadTime - c.BaseTime
+}
+
+// DeltaPercent returns by how many percent the test case got slower. It
+// returns false if the base duration is zero.
+func (c CaseDiff) DeltaPercent() (float64, bool) {
+	if c.BaseTime == 0 {
+		return 0, false
+	}
+	return c.Delta() / c.BaseTime * 100, true
+}
+
+// Slower returns true if the test case is in base and head and got slower
+// than allowed by the thresholds.
+func (t Thresholds) Slower(c CaseDiff) bool {
+	if c.BaseStatus == "" || c.HeadStatus == "" || c.Delta() <= 0 {
+		return false
+	}
+	if t.Absolute == 0 && t.Percent == 0 {
+		return false
+	}
+	if t.Absolute > 0 && c.Delta() < t.Absolute {
+		return false
+	}
+	if t.Percent > 0 {
+		p, ok := c.DeltaPercent()
+		// a test case that took no time in base is infinitely slower
+		if ok && p < t.Percent {
+			return false
+		}
+	}
+	return true
+}
+
+// Regression returns true if the test case is newly failing or got slower
+// than allowed by the thresholds. Added test cases that fail in head are
+// newly failing as well. Removed test cases are only regressions if
+// FailOnRemoved is set.
+func (d Diff) Regression(c CaseDiff) bool {
+	return c.Change == ChangeNewlyFailing || (c.Change == ChangeAdded && failing(c.HeadStatus)) ||
+		(c.Change == ChangeRemoved && d.FailOnRemoved) || d.Thresholds.Slower(c)
+}
+
+// Regressions returns the test cases that are newly failing, including added
+// ones that fail, got slower than allowed by the thresholds or, if
+// FailOnRemoved is set, were removed.
+func (d Diff) Regressions() []CaseDiff {
+	var regressions []CaseDiff
+	for _, c := range d.Cases {
+		if d.Regression(c) {
+			regressions = append(regressions, c)
+		}
+	}
+	return regressions
+}
+
+func (d Diff) changed(change Change) []CaseDiff {
+	var cases []CaseDiff
+	for _, c := range d.Cases {
+		if c.Change == change {
+			cases = append(cases, c)
+		}
+	}
+	return cases
+}
+
+func diffHeader() []string {
+	return []string{
+		"module",
+		"class",
+		"test",
+		"change",
+		"base status",
+		"head status",
+		"base duration [seconds]",
+		"head duration [seconds]",
+		"duration delta [seconds]",
+		"duration delta [percent]",
+		"regression",
+	}
+}
+
+func (d Diff) record(c CaseDiff) []string {
+	return []string{
+		c.Module,
+		c.Class,
+		c.Name,
+		string(c.Change),
+		string(c.BaseStatus),
+		string(c.HeadStatus),
+		formatSeconds(c.BaseTime),
+		formatSeconds(c.HeadTime),
+		formatSeconds(c.Delta()),
+		formatPercent(c),
+		strconv.FormatBool(d.Regression(c)),
+	}
+}
+
+func formatPercent(c CaseDiff) string {
+	p, ok := c.DeltaPercent()
+	if !ok {
+		return ""
+	}
+	return strconv.FormatFloat(p, 'f', 1, 64)
+}
+
+// WriteCSV writes one record per test case found in base or head.
+func (d Diff) WriteCSV(w io.Writer) error {
+	c := csv.NewWriter(w)
+	if err := c.Write(diffHeader()); err != nil {
+		return err
+	}
+	for _, cd := range d.Cases {
+		if err := c.Write(d.record(cd)); err != nil {
+			return err
+		}
+	}
+	c.Flush()
+	return c.Error()
+}
+
+// WriteTable writes the total durations followed by tables of the added,
+// removed, newly failing, fixed and slower test cases.
+func (d Diff) WriteTable(w io.Writer) error {
+	var base, head float64
+	var slower []CaseDiff
+	for _, c := range d.Cases {
+		base += c.BaseTime
+		head += c.HeadTime
+		if d.Thresholds.Slower(c) {
+			slower = append(slower, c)
+		}
+	}
+	sort.SliceStable(slower, func(i, j int) bool {
+		return slower[i].Delta() > slower[j].Delta()
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	write := func(cells ...string) {
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+
+	write("duration [seconds]", "base", "head", "delta")
+	write("", formatSeconds(base), formatSeconds(head), formatSeconds(head-base))
+
+	sections := []struct {
+		title string
+		cases []CaseDiff
+	}{
+		{"added", d.changed(ChangeAdded)},
+		{"removed", d.changed(ChangeRemoved)},
+		{"newly failing", d.changed(ChangeNewlyFailing)},
+		{"fixed", d.changed(ChangeFixed)},
+		{"slower", slower},
+	}
+	for _, s := range sections {
+		write()
+		write(fmt.Sprintf("%s (%d)", s.title, len(s.cases)))
+		if len(s.cases) == 0 {
+			continue
+		}
+		write("module", "class", "test", "base status", "head status", "base [seconds]", "head [seconds]", "delta [seconds]", "delta [percent]")
+		for _, c := range s.cases {
+			write(c.Module, c.Class, c.Name, string(c.BaseStatus), string(c.HeadStatus), formatSeconds(c.BaseTime), formatSeconds(c.HeadTime), formatSeconds(c.Delta()), formatPercent(c))
+		}
+	}
+
+	return tw.Flush()
+}
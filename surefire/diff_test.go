@@ -0,0 +1,150 @@
+package surefire
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func writeReport(t *testing.T, dir, name, report string) {
+	t.Helper()
+	err := os.WriteFile(filepath.Join(dir, name), []byte(report), 0600)
+	if err != nil {
+		t.Fatalf("failed to write report due to %s", err)
+	}
+}
+
+func TestDiffer(t *testing.T) {
+	base := t.TempDir()
+	writeReport(t, base, "TEST-org.hisp.dhis.analytics.data.AnalyticsServiceTest.xml", `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="10" tests="4" errors="0" skipped="0" failures="1">
+  <properties>
+    <property name="basedir" value="/home/runner/work/dhis2-core/dhis2-core/dhis-2/dhis-services/dhis-service-analytics"/>
+  </properties>
+  <testcase name="testMappingAggregation" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="2"/>
+  <testcase name="testGridAggregation" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="2"/>
+  <testcase name="testSetAggregation" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="2">
+    <failure message="boom" type="java.lang.AssertionError"/>
+  </testcase>
+  <testcase name="queryValidationResultTable" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="2"/>
+</testsuite>`)
+	head := t.TempDir()
+	writeReport(t, head, "TEST-org.hisp.dhis.analytics.data.AnalyticsServiceTest.xml", `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="10" tests="5" errors="1" skipped="0" failures="1">
+  <properties>
+    <property name="basedir" value="/home/runner/work/dhis2-core/dhis2-core/dhis-2/dhis-services/dhis-service-analytics"/>
+  </properties>
+  <testcase name="testMappingAggregation" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="3"/>
+  <testcase name="testGridAggregation" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="2.1">
+    <error message="boom" type="java.lang.NullPointerException"/>
+  </testcase>
+  <testcase name="testSetAggregation" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="2"/>
+  <testcase name="testNewAggregation" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="1"/>
+  <testcase name="testBrokenAggregation" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="1">
+    <failure message="boom" type="java.lang.AssertionError"/>
+  </testcase>
+</testsuite>`)
+
+	var w bytes.Buffer
+	d := Differ{Base: base, Head: head, Log: &w, Thresholds: Thresholds{Absolute: 0.5, Percent: 10}}
+
+	got, err := d.Diff()
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+
+	module := "dhis-service-analytics"
+	class := "org.hisp.dhis.analytics.data.AnalyticsServiceTest"
+	want := []CaseDiff{
+		{Module: module, Class: class, Name: "queryValidationResultTable", Change: ChangeRemoved, BaseStatus: StatusPassed, BaseTime: 2},
+		{Module: module, Class: class, Name: "testBrokenAggregation", Change: ChangeAdded, HeadStatus: StatusFailed, HeadTime: 1},
+		{Module: module, Class: class, Name: "testGridAggregation", Change: ChangeNewlyFailing, BaseStatus: StatusPassed, HeadStatus: StatusErrored, BaseTime: 2, HeadTime: 2.1},
+		{Module: module, Class: class, Name: "testMappingAggregation", Change: ChangeNone, BaseStatus: StatusPassed, HeadStatus: StatusPassed, BaseTime: 2, HeadTime: 3},
+		{Module: module, Class: class, Name: "testNewAggregation", Change: ChangeAdded, HeadStatus: StatusPassed, HeadTime: 1},
+		{Module: module, Class: class, Name: "testSetAggregation", Change: ChangeFixed, BaseStatus: StatusFailed, HeadStatus: StatusPassed, BaseTime: 2, HeadTime: 2},
+	}
+	if diff := cmp.Diff(want, got.Cases); diff != "" {
+		t.Errorf("Diff() mismatch (-want +got): \n%s", diff)
+	}
+
+	var regressions []string
+	for _, c := range got.Regressions() {
+		regressions = append(regressions, c.Name)
+	}
+	if diff := cmp.Diff([]string{"testBrokenAggregation", "testGridAggregation", "testMappingAggregation"}, regressions); diff != "" {
+		t.Errorf("Regressions() mismatch (-want +got): \n%s", diff)
+	}
+
+	d.FailOnRemoved = true
+	got, err = d.Diff()
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+
+	regressions = nil
+	for _, c := range got.Regressions() {
+		regressions = append(regressions, c.Name)
+	}
+	if diff := cmp.Diff([]string{"queryValidationResultTable", "testBrokenAggregation", "testGridAggregation", "testMappingAggregation"}, regressions); diff != "" {
+		t.Errorf("Regressions() with removed test cases mismatch (-want +got): \n%s", diff)
+	}
+}
+
+func TestThresholdsSlower(t *testing.T) {
+	tc := map[string]struct {
+		thresholds Thresholds
+		c          CaseDiff
+		want       bool
+	}{
+		"NoThresholds": {
+			c:    CaseDiff{BaseStatus: StatusPassed, HeadStatus: StatusPassed, BaseTime: 1, HeadTime: 10},
+			want: false,
+		},
+		"AboveAbsolute": {
+			thresholds: Thresholds{Absolute: 1},
+			c:          CaseDiff{BaseStatus: StatusPassed, HeadStatus: StatusPassed, BaseTime: 1, HeadTime: 2.5},
+			want:       true,
+		},
+		"BelowAbsolute": {
+			thresholds: Thresholds{Absolute: 1},
+			c:          CaseDiff{BaseStatus: StatusPassed, HeadStatus: StatusPassed, BaseTime: 1, HeadTime: 1.5},
+			want:       false,
+		},
+		"AboveAbsoluteButBelowPercent": {
+			thresholds: Thresholds{Absolute: 1, Percent: 50},
+			c:          CaseDiff{BaseStatus: StatusPassed, HeadStatus: StatusPassed, BaseTime: 10, HeadTime: 12},
+			want:       false,
+		},
+		"AbovePercent": {
+			thresholds: Thresholds{Percent: 50},
+			c:          CaseDiff{BaseStatus: StatusPassed, HeadStatus: StatusPassed, BaseTime: 0.1, HeadTime: 0.2},
+			want:       true,
+		},
+		"AbovePercentIfBaseTookNoTime": {
+			thresholds: Thresholds{Percent: 50},
+			c:          CaseDiff{BaseStatus: StatusPassed, HeadStatus: StatusPassed, BaseTime: 0, HeadTime: 0.2},
+			want:       true,
+		},
+		"Faster": {
+			thresholds: Thresholds{Absolute: 1},
+			c:          CaseDiff{BaseStatus: StatusPassed, HeadStatus: StatusPassed, BaseTime: 5, HeadTime: 1},
+			want:       false,
+		},
+		"Added": {
+			thresholds: Thresholds{Absolute: 1},
+			c:          CaseDiff{HeadStatus: StatusPassed, HeadTime: 5},
+			want:       false,
+		},
+	}
+
+	for k, v := range tc {
+		t.Run(k, func(t *testing.T) {
+			if got := v.thresholds.Slower(v.c); got != v.want {
+				t.Errorf("Slower() = %t, want %t", got, v.want)
+			}
+		})
+	}
+}
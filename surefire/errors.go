@@ -0,0 +1,75 @@
+package surefire
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Phase is the step in which processing a file failed.
+type Phase string
+
+const (
+	// PhaseWalk means the file or directory could not be read while walking
+	// the source directory.
+	PhaseWalk   Phase = "walk"
+	PhaseOpen   Phase = "open"
+	PhaseDecode Phase = "decode"
+	// PhaseWrite means the converted report could not be written.
+	PhaseWrite Phase = "write"
+)
+
+// FileError records why processing a file failed.
+type FileError struct {
+	Path  string
+	Phase Phase
+	Err   error
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("failed to %s %q: %s", e.Phase, e.Path, e.Err)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// fileError returns a FileError or nil if err is nil.
+func fileError(phase Phase, path string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &FileError{Path: path, Phase: phase, Err: err}
+}
+
+// FileErrors holds all files that failed to be processed in the order they
+// failed.
+type FileErrors []*FileError
+
+func (e FileErrors) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "failed to process %d file(s):", len(e))
+	for _, fe := range e {
+		fmt.Fprintf(&sb, "\n\t%s", fe)
+	}
+	return sb.String()
+}
+
+// Unwrap allows errors.Is and errors.As to match any of the file errors.
+func (e FileErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, fe := range e {
+		errs[i] = fe
+	}
+	return errs
+}
+
+// asFileError returns err as FileError. Errors that do not already record a
+// phase are attributed to writing as that is the last step of a conversion.
+func asFileError(path string, err error) *FileError {
+	var fe *FileError
+	if errors.As(err, &fe) {
+		return fe
+	}
+	return &FileError{Path: path, Phase: PhaseWrite, Err: err}
+}
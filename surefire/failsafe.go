@@ -0,0 +1,217 @@
+package surefire
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+)
+
+// Plugin is the Maven plugin that wrote a report.
+type Plugin string
+
+const (
+	PluginSurefire Plugin = "surefire"
+	// PluginFailsafe runs integration tests and writes its reports into
+	// target/failsafe-reports.
+	PluginFailsafe Plugin = "failsafe"
+)
+
+// failsafeReports is the directory maven-failsafe-plugin writes its reports
+// into.
+const failsafeReports = "failsafe-reports"
+
+// failsafeSummaryFile is the name of the summary maven-failsafe-plugin writes
+// next to its reports.
+const failsafeSummaryFile = "failsafe-summary.xml"
+
+// pluginOf returns the plugin that wrote the report in file. Reports found in
+// a failsafe-reports directory were written by Failsafe and all others by
+// Surefire.
+func pluginOf(file string) Plugin {
+	for dir := filepath.Dir(file); ; {
+		if filepath.Base(dir) == failsafeReports {
+			return PluginFailsafe
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return PluginSurefire
+		}
+		dir = parent
+	}
+}
+
+// isFailsafeSummary returns true if file is the summary written by
+// maven-failsafe-plugin instead of a report.
+func isFailsafeSummary(file string) bool {
+	return filepath.Base(file) == failsafeSummaryFile
+}
+
+// FailsafeSummary is the summary of all integration tests of a module which
+// maven-failsafe-plugin writes into failsafe-summary.xml.
+type FailsafeSummary struct {
+	// File is the path of the summary.
+	File string `xml:"-" json:"file"`
+	// Result is the exit code of the run. It is empty if all tests passed.
+	Result  string `xml:"result,attr" json:"result,omitempty"`
+	Timeout bool   `xml:"timeout,attr" json:"timeout"`
+	// Completed is the number of tests that ran.
+	Completed      Count  `xml:"completed" json:"completed"`
+	Errors         Count  `xml:"errors" json:"errors"`
+	Failures       Count  `xml:"failures" json:"failures"`
+	Skipped        Count  `xml:"skipped" json:"skipped"`
+	FailureMessage string `xml:"failureMessage" json:"failureMessage,omitempty"`
+	// Project is the Maven project the summary was found in. It is nil if
+	// there is no pom.xml in any directory above the summary.
+	Project *Project `xml:"-" json:"project,omitempty"`
+}
+
+// Module returns the artifactId of the project the summary was found in or
+// an empty string if it is not known.
+func (fs FailsafeSummary) Module() string {
+	if fs.Project == nil {
+		return ""
+	}
+	return fs.Project.ArtifactID
+}
+
+// countText is a Count decoded from the text of an element.
+type countText Count
+
+func (c *countText) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	line, _ := d.InputPos()
+	var text string
+	if err := d.DecodeElement(&text, &start); err != nil {
+		return err
+	}
+	v, err := parseCount(text)
+	if err != nil {
+		return &AttrError{Line: line, Attr: start.Name.Local, Value: text, Err: err}
+	}
+	*c = countText{Value: v, Text: text}
+	return nil
+}
+
+// readFailsafeSummary decodes the failsafe-summary.xml in r.
+func readFailsafeSummary(r io.Reader) (FailsafeSummary, error) {
+	var s struct {
+		XMLName        xml.Name  `xml:"failsafe-summary"`
+		Result         string    `xml:"result,attr"`
+		Timeout        bool      `xml:"timeout,attr"`
+		Completed      countText `xml:"completed"`
+		Errors         countText `xml:"errors"`
+		Failures       countText `xml:"failures"`
+		Skipped        countText `xml:"skipped"`
+		FailureMessage string    `xml:"failureMessage"`
+	}
+	if err := xml.NewDecoder(r).Decode(&s); err != nil {
+		return FailsafeSummary{}, err
+	}
+	return FailsafeSummary{
+		Result:         s.Result,
+		Timeout:        s.Timeout,
+		Completed:      Count(s.Completed),
+		Errors:         Count(s.Errors),
+		Failures:       Count(s.Failures),
+		Skipped:        Count(s.Skipped),
+		FailureMessage: s.FailureMessage,
+	}, nil
+}
+
+// decodeFailsafeSummary decodes the failsafe-summary.xml rf. Errors are
+// returned as FileError.
+func decodeFailsafeSummary(rf reportFile) (FailsafeSummary, error) {
+	r, err := rf.fsys.Open(rf.name)
+	if err != nil {
+		return FailsafeSummary{}, fileError(PhaseOpen, rf.path, err)
+	}
+	defer r.Close()
+
+	s, err := readFailsafeSummary(r)
+	if err != nil {
+		return FailsafeSummary{}, fileError(PhaseDecode, rf.path, err)
+	}
+	s.File = rf.path
+	return s, nil
+}
+
+// failsafeSummaryName is the name of the file the Failsafe summaries are
+// converted to without its extension.
+const failsafeSummaryName = "failsafe-summary"
+
+func failsafeHeader() []string {
+	return []string{
+		"module",
+		"failsafe summary",
+		"result",
+		"timeout",
+		"completed [number]",
+		"errors [number]",
+		"failures [number]",
+		"skipped [number]",
+		"failure message",
+	}
+}
+
+func (s FailsafeSummary) record() []string {
+	return []string{
+		s.Module(),
+		s.File,
+		s.Result,
+		strconv.FormatBool(s.Timeout),
+		s.Completed.String(),
+		s.Errors.String(),
+		s.Failures.String(),
+		s.Skipped.String(),
+		s.FailureMessage,
+	}
+}
+
+// writeFailsafeSummaries writes the summaries into w in the given format.
+func writeFailsafeSummaries(w io.Writer, format Format, summaries []FailsafeSummary) error {
+	switch format {
+	case FormatCSV, "":
+		c := csv.NewWriter(w)
+		c.Write(failsafeHeader())
+		for _, s := range summaries {
+			c.Write(s.record())
+		}
+		c.Flush()
+		return c.Error()
+	case FormatHTML:
+		table := htmlTable{Header: failsafeHeader()}
+		for _, s := range summaries {
+			table.Records = append(table.Records, s.record())
+		}
+		return writeHTMLTable(w, "Failsafe summary", table)
+	case FormatXLSX:
+		sheet := xlsxSheet{name: "Failsafe summary", header: failsafeHeader()}
+		for _, s := range summaries {
+			sheet.records = append(sheet.records, s.record())
+		}
+		return writeWorkbook(w, []xlsxSheet{sheet})
+	case FormatParquet:
+		return writeParquetFailsafeSummaries(w, summaries)
+	case FormatOpenMetrics:
+		return writeOpenMetricsFailsafeSummaries(w, summaries)
+	case FormatJSON:
+		b, err := json.MarshalIndent(summaries, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", b)
+		return err
+	case FormatNDJSON:
+		enc := json.NewEncoder(w)
+		for _, s := range summaries {
+			if err := enc.Encode(s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown format %q, valid formats are %v", format, Formats())
+}
@@ -0,0 +1,145 @@
+package surefire
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPluginOf(t *testing.T) {
+	tc := map[string]struct {
+		in   string
+		want Plugin
+	}{
+		"Surefire":     {in: filepath.Join("core", "target", "surefire-reports", "TEST-a.xml"), want: PluginSurefire},
+		"Failsafe":     {in: filepath.Join("core", "target", "failsafe-reports", "TEST-a.xml"), want: PluginFailsafe},
+		"FailsafeNest": {in: filepath.Join("target", "failsafe-reports", "nested", "TEST-a.xml"), want: PluginFailsafe},
+		"NoDirectory":  {in: "TEST-a.xml", want: PluginSurefire},
+	}
+
+	for k, v := range tc {
+		t.Run(k, func(t *testing.T) {
+			if got := pluginOf(v.in); got != v.want {
+				t.Errorf("pluginOf(%q) = %q but want %q", v.in, got, v.want)
+			}
+		})
+	}
+}
+
+func TestReadFailsafeSummary(t *testing.T) {
+	t.Run("Failed", func(t *testing.T) {
+		got, err := readFailsafeSummary(strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?>
+<failsafe-summary xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" result="255" timeout="false">
+    <completed>12</completed>
+    <errors>1</errors>
+    <failures>2</failures>
+    <skipped>3</skipped>
+    <failureMessage>There are test failures.</failureMessage>
+</failsafe-summary>`))
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		want := FailsafeSummary{
+			Result:         "255",
+			Completed:      Count{Value: 12, Text: "12"},
+			Errors:         Count{Value: 1, Text: "1"},
+			Failures:       Count{Value: 2, Text: "2"},
+			Skipped:        Count{Value: 3, Text: "3"},
+			FailureMessage: "There are test failures.",
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("readFailsafeSummary() mismatch (-want +got): \n%s", diff)
+		}
+	})
+
+	t.Run("FailsOnInvalidCount", func(t *testing.T) {
+		_, err := readFailsafeSummary(strings.NewReader(`<failsafe-summary>
+    <completed>many</completed>
+</failsafe-summary>`))
+
+		if err == nil {
+			t.Fatal("expected an error but got none")
+		}
+	})
+
+	t.Run("FailsOnOtherRoot", func(t *testing.T) {
+		_, err := readFailsafeSummary(strings.NewReader(`<testsuite name="a"/>`))
+
+		if err == nil {
+			t.Fatal("expected an error but got none")
+		}
+	})
+}
+
+func TestConverterFailsafe(t *testing.T) {
+	src := t.TempDir()
+	writeProject(t, src, `<project>
+  <groupId>org.hisp.dhis</groupId>
+  <artifactId>dhis-test-e2e</artifactId>
+</project>`, "org.hisp.dhis.UnitTest")
+	failsafe := filepath.Join(src, "target", "failsafe-reports")
+	if err := os.MkdirAll(failsafe, 0750); err != nil {
+		t.Fatalf("failed to create failsafe-reports due to %s", err)
+	}
+	writeReport(t, failsafe, "TEST-org.hisp.dhis.IntegrationTest.xml", `<testsuite name="org.hisp.dhis.IntegrationTest" time="2" tests="1" errors="0" skipped="0" failures="0">
+  <testcase name="test" classname="org.hisp.dhis.IntegrationTest" time="2"/>
+</testsuite>`)
+	writeReport(t, failsafe, "failsafe-summary.xml", `<failsafe-summary result="255" timeout="false">
+    <completed>1</completed>
+    <errors>0</errors>
+    <failures>1</failures>
+    <skipped>0</skipped>
+    <failureMessage/>
+</failsafe-summary>`)
+
+	var w bytes.Buffer
+	c := Converter{From: src, Log: &w, Concat: true, Strict: true, Columns: []string{"module", "class", "plugin"}}
+	dest := t.TempDir()
+
+	err := c.To(dest)
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+	if w.Len() > 0 {
+		t.Errorf("expected no logs but got %q", w.String())
+	}
+
+	got := readCSV(t, filepath.Join(dest, "surefire.csv"))
+	want := [][]string{
+		{"module", "class", "plugin"},
+		{"dhis-test-e2e", "org.hisp.dhis.IntegrationTest", "failsafe"},
+		{"dhis-test-e2e", "org.hisp.dhis.UnitTest", "surefire"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("To() mismatch (-want +got): \n%s", diff)
+	}
+
+	got = readCSV(t, filepath.Join(dest, "failsafe-summary.csv"))
+	want = [][]string{
+		{"module", "failsafe summary", "result", "timeout", "completed [number]", "errors [number]", "failures [number]", "skipped [number]", "failure message"},
+		{"dhis-test-e2e", filepath.Join(failsafe, "failsafe-summary.xml"), "255", "false", "1", "0", "1", "0", ""},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("To() summary mismatch (-want +got): \n%s", diff)
+	}
+}
+
+func readCSV(t *testing.T, name string) [][]string {
+	t.Helper()
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("failed to open CSV due to %s", err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read CSV due to %s", err)
+	}
+	return records
+}
@@ -0,0 +1,111 @@
+package surefire
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// skipDirs are directories that never contain reports but can be large so
+// they are not walked.
+var skipDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+}
+
+// fileFilter selects the reports that are walked.
+type fileFilter struct {
+	// include are the glob patterns of the reports to walk. All XML files
+	// are walked if it is empty.
+	include []string
+	// exclude are the glob patterns of reports not to walk even if they are
+	// included.
+	exclude []string
+	// followSymlinks walks the directories symbolic links point to.
+	followSymlinks bool
+}
+
+// validate returns an error if any pattern is malformed.
+func (ff fileFilter) validate() error {
+	for _, p := range append(append([]string{}, ff.include...), ff.exclude...) {
+		if err := validGlob(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// included returns true if the report is included and not excluded. rel is
+// the path of the report relative to the walked directory and abs its
+// absolute path, both using forward slashes.
+func (ff fileFilter) included(rel, abs string) bool {
+	if ff.excluded(rel, abs) {
+		return false
+	}
+	if len(ff.include) == 0 {
+		return strings.ToLower(path.Ext(rel)) == ".xml"
+	}
+	return matchAny(ff.include, rel, abs)
+}
+
+// excluded returns true if the report matches any exclude pattern.
+func (ff fileFilter) excluded(rel, abs string) bool {
+	return matchAny(ff.exclude, rel, abs)
+}
+
+// matchAny returns true if any pattern matches rel. Patterns starting with
+// **/ are matched against abs as well so **/surefire-reports/*.xml matches
+// the reports in the walked directory if it is a surefire-reports directory
+// itself.
+func matchAny(patterns []string, rel, abs string) bool {
+	for _, p := range patterns {
+		if matchGlob(p, rel) || (strings.HasPrefix(p, "**/") && matchGlob(p, abs)) {
+			return true
+		}
+	}
+	return false
+}
+
+// validGlob returns an error if pattern is not a valid glob.
+func validGlob(pattern string) error {
+	for _, segment := range strings.Split(pattern, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchGlob reports whether name matches the glob pattern. Both are split
+// into segments at forward slashes which are matched using path.Match. A
+// segment of ** matches zero or more segments like **/surefire-reports/*.xml
+// matches surefire-reports/TEST-a.xml and
+// core/target/surefire-reports/TEST-a.xml.
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// collapse repeated ** as they match the same
+			for len(pattern) > 1 && pattern[1] == "**" {
+				pattern = pattern[1:]
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
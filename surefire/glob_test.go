@@ -0,0 +1,40 @@
+package surefire
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tc := map[string]struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		"Literal":                     {pattern: "a/b.xml", name: "a/b.xml", want: true},
+		"Star":                        {pattern: "a/*.xml", name: "a/b.xml", want: true},
+		"StarDoesNotCrossDirectories": {pattern: "*.xml", name: "a/b.xml", want: false},
+		"DoubleStarMatchesNone":       {pattern: "**/surefire-reports/TEST-*.xml", name: "surefire-reports/TEST-a.xml", want: true},
+		"DoubleStarMatchesMany":       {pattern: "**/surefire-reports/TEST-*.xml", name: "core/target/surefire-reports/TEST-a.xml", want: true},
+		"DoubleStarInTheMiddle":       {pattern: "core/**/TEST-*.xml", name: "core/target/surefire-reports/TEST-a.xml", want: true},
+		"DoubleStarAtTheEnd":          {pattern: "src/test/**", name: "src/test/resources/a.xml", want: true},
+		"RepeatedDoubleStar":          {pattern: "**/**/a.xml", name: "a.xml", want: true},
+		"DifferentDirectory":          {pattern: "**/surefire-reports/TEST-*.xml", name: "core/src/test/resources/TEST-a.xml", want: false},
+		"DifferentFile":               {pattern: "**/surefire-reports/TEST-*.xml", name: "surefire-reports/a.xml", want: false},
+		"AbsolutePath":                {pattern: "**/surefire-reports/TEST-*.xml", name: "/home/core/target/surefire-reports/TEST-a.xml", want: true},
+	}
+
+	for k, v := range tc {
+		t.Run(k, func(t *testing.T) {
+			if got := matchGlob(v.pattern, v.name); got != v.want {
+				t.Errorf("matchGlob(%q, %q) = %t but want %t", v.pattern, v.name, got, v.want)
+			}
+		})
+	}
+}
+
+func TestValidGlob(t *testing.T) {
+	if err := validGlob("**/surefire-reports/TEST-*.xml"); err != nil {
+		t.Errorf("expected no error but got %s", err)
+	}
+	if err := validGlob("**/[a-.xml"); err == nil {
+		t.Error("expected an error but got none")
+	}
+}
@@ -0,0 +1,188 @@
+package surefire
+
+import (
+	"embed"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+)
+
+var htmlFuncs = template.FuncMap{
+	"seconds": formatSeconds,
+	"trim":    strings.TrimSpace,
+}
+
+//go:embed html
+var htmlFiles embed.FS
+
+var htmlTemplate = template.Must(template.New("report").Funcs(htmlFuncs).ParseFS(htmlFiles, "html/report.html.tmpl"))
+
+// buckets are the upper bounds in seconds of the bars of the duration
+// histogram. Tests slower than the last bound are counted in a last bar.
+var buckets = []struct {
+	label string
+	upper float64
+}{
+	{label: "< 10ms", upper: 0.01},
+	{label: "10ms - 100ms", upper: 0.1},
+	{label: "100ms - 1s", upper: 1},
+	{label: "1s - 10s", upper: 10},
+	{label: "10s - 1m", upper: 60},
+}
+
+// htmlEncoder writes one self-contained HTML report of all test suites. The
+// tests table uses the same columns as CSV. The report is only written on
+// Close as it needs all test suites.
+type htmlEncoder struct {
+	w       io.Writer
+	opts    recordOptions
+	columns []column
+	records [][]string
+	agg     *aggregator
+	// durations are the durations of all test cases in seconds.
+	durations []float64
+}
+
+// htmlReport is the data of the HTML template.
+type htmlReport struct {
+	CSS   template.CSS
+	JS    template.JS
+	Title string
+	Total Aggregate
+	// Modules are the module aggregates without the class column.
+	Modules htmlTable
+	Classes htmlTable
+	// Tests has the same columns as CSV.
+	Tests     htmlTable
+	Histogram []htmlBar
+	Slowest   []TestResult
+	// Failures are the failed and errored test cases.
+	Failures []TestResult
+}
+
+// htmlTable is a table that can be sorted and filtered.
+type htmlTable struct {
+	Header  []string
+	Records [][]string
+}
+
+type htmlBar struct {
+	Label string
+	Count int
+	// Percent is the count relative to the highest bar.
+	Percent int
+}
+
+func newHTMLEncoder(w io.Writer, opts recordOptions) (*htmlEncoder, error) {
+	columns, err := opts.selected()
+	if err != nil {
+		return nil, err
+	}
+	return &htmlEncoder{w: w, opts: opts, columns: columns, agg: newAggregator()}, nil
+}
+
+func (he *htmlEncoder) encode(suite TestSuite) error {
+	he.agg.add(suite)
+	for _, c := range suite.Cases {
+		for _, r := range rows(suite, c, he.opts.attempts) {
+			he.records = append(he.records, record(he.columns, r))
+		}
+		he.durations = append(he.durations, c.Time.Value)
+	}
+	return nil
+}
+
+func (he *htmlEncoder) Close() error {
+	css, js, err := htmlAssets()
+	if err != nil {
+		return err
+	}
+
+	summary := he.agg.summary()
+	var modules, classes [][]string
+	for _, m := range summary.Modules {
+		r := m.record()
+		modules = append(modules, append([]string{r[0]}, r[2:]...))
+	}
+	for _, c := range summary.Classes {
+		classes = append(classes, c.record())
+	}
+	var failures []TestResult
+	for _, t := range summary.NotPassed {
+		if t.Status == StatusFailed || t.Status == StatusErrored {
+			failures = append(failures, t)
+		}
+	}
+	h := summaryHeader()
+
+	return htmlTemplate.ExecuteTemplate(he.w, "report", htmlReport{
+		CSS:       css,
+		JS:        js,
+		Title:     "Surefire test report",
+		Total:     summary.Total(),
+		Modules:   htmlTable{Header: append([]string{h[0]}, h[2:]...), Records: modules},
+		Classes:   htmlTable{Header: h, Records: classes},
+		Tests:     htmlTable{Header: header(he.columns), Records: he.records},
+		Histogram: histogram(he.durations),
+		Slowest:   summary.Slowest,
+		Failures:  failures,
+	})
+}
+
+// writeHTMLTable writes a self-contained HTML page with a single table.
+func writeHTMLTable(w io.Writer, title string, table htmlTable) error {
+	css, js, err := htmlAssets()
+	if err != nil {
+		return err
+	}
+	return htmlTemplate.ExecuteTemplate(w, "page", htmlPage{CSS: css, JS: js, Title: title, Table: table})
+}
+
+// htmlPage is the data of a page with a single table.
+type htmlPage struct {
+	CSS   template.CSS
+	JS    template.JS
+	Title string
+	Table htmlTable
+}
+
+// htmlAssets returns the stylesheet and script embedded into every page.
+func htmlAssets() (template.CSS, template.JS, error) {
+	css, err := htmlFiles.ReadFile("html/report.css")
+	if err != nil {
+		return "", "", err
+	}
+	js, err := htmlFiles.ReadFile("html/report.js")
+	if err != nil {
+		return "", "", err
+	}
+	return template.CSS(css), template.JS(js), nil
+}
+
+// histogram counts the test case durations in seconds per bucket.
+func histogram(durations []float64) []htmlBar {
+	bars := make([]htmlBar, len(buckets)+1)
+	for i, b := range buckets {
+		bars[i].Label = b.label
+	}
+	bars[len(buckets)].Label = ">= 1m"
+
+	for _, d := range durations {
+		i := sort.Search(len(buckets), func(i int) bool {
+			return d < buckets[i].upper
+		})
+		bars[i].Count++
+	}
+
+	var highest int
+	for _, b := range bars {
+		highest = max(highest, b.Count)
+	}
+	if highest > 0 {
+		for i := range bars {
+			bars[i].Percent = bars[i].Count * 100 / highest
+		}
+	}
+	return bars
+}
@@ -0,0 +1,46 @@
+// sorts a table by the clicked column and filters its rows by the text in
+// the filter input before it
+(function () {
+  "use strict";
+
+  function compare(a, b) {
+    var x = Number(a), y = Number(b);
+    if (a !== "" && b !== "" && !isNaN(x) && !isNaN(y)) {
+      return x - y;
+    }
+    return a.localeCompare(b);
+  }
+
+  document.querySelectorAll("table.sortable").forEach(function (table) {
+    var headers = table.querySelectorAll("thead th");
+    headers.forEach(function (th, column) {
+      th.addEventListener("click", function () {
+        var ascending = th.getAttribute("aria-sort") !== "ascending";
+        headers.forEach(function (h) {
+          h.removeAttribute("aria-sort");
+        });
+        th.setAttribute("aria-sort", ascending ? "ascending" : "descending");
+
+        var body = table.tBodies[0];
+        var rows = Array.prototype.slice.call(body.rows);
+        rows.sort(function (a, b) {
+          var c = compare(a.cells[column].textContent, b.cells[column].textContent);
+          return ascending ? c : -c;
+        });
+        rows.forEach(function (row) {
+          body.appendChild(row);
+        });
+      });
+    });
+
+    var filter = table.previousElementSibling;
+    if (filter && filter.classList.contains("filter")) {
+      filter.addEventListener("input", function () {
+        var text = filter.value.toLowerCase();
+        Array.prototype.forEach.call(table.tBodies[0].rows, function (row) {
+          row.hidden = text !== "" && row.textContent.toLowerCase().indexOf(text) < 0;
+        });
+      });
+    }
+  });
+})();
@@ -0,0 +1,72 @@
+package surefire
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHistogram(t *testing.T) {
+	got := histogram([]float64{0.001, 0.05, 0.06, 1, 120})
+
+	want := []htmlBar{
+		{Label: "< 10ms", Count: 1, Percent: 50},
+		{Label: "10ms - 100ms", Count: 2, Percent: 100},
+		{Label: "100ms - 1s", Count: 0, Percent: 0},
+		{Label: "1s - 10s", Count: 1, Percent: 50},
+		{Label: "10s - 1m", Count: 0, Percent: 0},
+		{Label: ">= 1m", Count: 1, Percent: 50},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("histogram() mismatch (-want +got): \n%s", diff)
+	}
+}
+
+func TestConverterHTML(t *testing.T) {
+	src := t.TempDir()
+	writeReport(t, src, "TEST-org.hisp.dhis.HtmlTest.xml", `<testsuite name="org.hisp.dhis.HtmlTest" time="3" tests="3" errors="0" skipped="0" failures="1">
+  <testcase name="slow" classname="org.hisp.dhis.HtmlTest" time="2.5"/>
+  <testcase name="fast" classname="org.hisp.dhis.HtmlTest" time="0.001"/>
+  <testcase name="broken" classname="org.hisp.dhis.HtmlTest" time="0.4">
+    <failure message="expected &lt;1&gt; but was &lt;2&gt;" type="org.opentest4j.AssertionFailedError">at org.hisp.dhis.HtmlTest.broken(HtmlTest.java:42)</failure>
+  </testcase>
+</testsuite>`)
+
+	var w bytes.Buffer
+	dest := t.TempDir()
+	err := Converter{From: src, Log: &w, Concat: true, Format: FormatHTML, Columns: []string{"class", "test", "status"}}.To(dest)
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dest, "surefire.html"))
+	if err != nil {
+		t.Fatalf("failed to read report due to %s", err)
+	}
+	got := string(b)
+	for _, want := range []string{
+		"<title>Surefire test report</title>",
+		"3 tests, 1 failures, 0 errors, 0 skipped in 2.901 seconds",
+		// the tests table has the selected columns
+		"<thead><tr><th>class</th><th>test</th><th>test status</th></tr></thead>",
+		"<tr><td>org.hisp.dhis.HtmlTest</td><td>broken</td><td>failed</td></tr>",
+		// the slowest test is listed first
+		"<tr><td></td><td>org.hisp.dhis.HtmlTest</td><td>slow</td><td>2.500</td></tr>",
+		// failure messages are escaped
+		"expected &lt;1&gt; but was &lt;2&gt;",
+		"at org.hisp.dhis.HtmlTest.broken(HtmlTest.java:42)",
+		`<div class="bar" style="width: 100%"></div>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected report to contain %q", want)
+		}
+	}
+	// the report must work offline
+	if strings.Contains(got, "<script src") || strings.Contains(got, "<link") {
+		t.Error("expected report to embed its scripts and styles")
+	}
+}
@@ -0,0 +1,134 @@
+package surefire
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// jsonEncoder writes one JSON document of a test suite. Suites are written
+// into one JSON array if array is true or if there is more than one suite
+// like in reports with a testsuites root so every file is valid JSON.
+type jsonEncoder struct {
+	w     io.Writer
+	array bool
+	n     int
+	// first is the document of the first suite which is held back until it
+	// is known whether it is the only one unless array is true.
+	first []byte
+}
+
+// jsonSuite is a test suite together with the Maven module it ran in.
+type jsonSuite struct {
+	Module  string `json:"module"`
+	Basedir string `json:"basedir"`
+	TestSuite
+}
+
+func (je *jsonEncoder) encode(suite TestSuite) error {
+	b, err := json.MarshalIndent(jsonSuite{
+		Module:    suite.Module(),
+		Basedir:   suite.Basedir(),
+		TestSuite: suite,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	je.n++
+	if !je.array && je.n == 1 {
+		je.first = b
+		return nil
+	}
+
+	var sep string
+	switch {
+	case je.n == 1:
+		sep = "[\n"
+	case je.first != nil:
+		sep = "[\n" + string(je.first) + ",\n"
+		je.first = nil
+	default:
+		sep = ",\n"
+	}
+	if _, err := io.WriteString(je.w, sep); err != nil {
+		return err
+	}
+	_, err = je.w.Write(b)
+	return err
+}
+
+func (je *jsonEncoder) Close() error {
+	var end string
+	switch {
+	case je.array && je.n == 0:
+		end = "[]\n"
+	case je.first != nil:
+		end = string(je.first) + "\n"
+	case je.n > 0:
+		end = "\n]\n"
+	}
+	_, err := io.WriteString(je.w, end)
+	return err
+}
+
+// ndjsonEncoder writes one JSON document per line and test case.
+type ndjsonEncoder struct {
+	w io.Writer
+}
+
+// ndjsonRecord is a test case together with the suite and Maven module it
+// ran in.
+type ndjsonRecord struct {
+	Module  string      `json:"module"`
+	Basedir string      `json:"basedir"`
+	Project *Project    `json:"project,omitempty"`
+	Plugin  Plugin      `json:"plugin,omitempty"`
+	Archive string      `json:"archive,omitempty"`
+	Suite   ndjsonSuite `json:"suite"`
+	Case    TestCase    `json:"case"`
+}
+
+// ndjsonSuite is a test suite without its properties and test cases so they
+// are not repeated on every line. Properties are therefore lost in NDJSON;
+// they are written by the JSON and SQLite formats.
+type ndjsonSuite struct {
+	Name     string  `json:"name"`
+	Time     Seconds `json:"time"`
+	Tests    Count   `json:"tests"`
+	Errors   Count   `json:"errors"`
+	Skipped  Count   `json:"skipped"`
+	Failures Count   `json:"failures"`
+}
+
+func (ne *ndjsonEncoder) encode(suite TestSuite) error {
+	for _, c := range suite.Cases {
+		if err := ne.encodeCase(suite, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ne *ndjsonEncoder) encodeCase(suite TestSuite, c TestCase) error {
+	// json.Encoder terminates every value with a newline
+	return json.NewEncoder(ne.w).Encode(ndjsonRecord{
+		Module:  suite.Module(),
+		Basedir: suite.Basedir(),
+		Project: suite.Project,
+		Plugin:  suite.Plugin,
+		Archive: suite.Archive,
+		Suite: ndjsonSuite{
+			Name:     suite.Name,
+			Time:     suite.Time,
+			Tests:    suite.Tests,
+			Errors:   suite.Errors,
+			Skipped:  suite.Skipped,
+			Failures: suite.Failures,
+		},
+		Case: c,
+	})
+}
+
+func (ne *ndjsonEncoder) Close() error {
+	return nil
+}
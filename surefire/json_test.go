@@ -0,0 +1,230 @@
+package surefire
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const jsonTestReport = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:noNamespaceSchemaLocation="https://maven.apache.org/surefire/maven-surefire-plugin/xsd/surefire-test-report-3.0.xsd" version="3.0" name="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="2.5" tests="2" errors="0" skipped="0" failures="1">
+  <properties>
+    <property name="basedir" value="/home/runner/work/dhis2-core/dhis2-core/dhis-2/dhis-services/dhis-service-analytics"/>
+  </properties>
+  <testcase name="testMappingAggregation" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="1.1">
+    <failure message="expected: &lt;1&gt; but was: &lt;2&gt;" type="org.opentest4j.AssertionFailedError">trace</failure>
+  </testcase>
+  <testcase name="testSetAggregation" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="1.4"/>
+</testsuite>`
+
+func TestJSONEncoder(t *testing.T) {
+	suites, err := decode(strings.NewReader(jsonTestReport), false)
+	if err != nil {
+		t.Fatalf("failed to decode report due to %s", err)
+	}
+	suite := suites[0]
+
+	document := `{
+  "module": "dhis-service-analytics",
+  "basedir": "/home/runner/work/dhis2-core/dhis2-core/dhis-2/dhis-services/dhis-service-analytics",
+  "name": "org.hisp.dhis.analytics.data.AnalyticsServiceTest",
+  "time": 2.5,
+  "tests": 2,
+  "errors": 0,
+  "skipped": 0,
+  "failures": 1,
+  "properties": [
+    {
+      "name": "basedir",
+      "value": "/home/runner/work/dhis2-core/dhis2-core/dhis-2/dhis-services/dhis-service-analytics"
+    }
+  ],
+  "cases": [
+    {
+      "name": "testMappingAggregation",
+      "className": "org.hisp.dhis.analytics.data.AnalyticsServiceTest",
+      "time": 1.1,
+      "failure": {
+        "message": "expected: \u003c1\u003e but was: \u003c2\u003e",
+        "type": "org.opentest4j.AssertionFailedError",
+        "stackTrace": "trace"
+      },
+      "status": "failed",
+      "reruns": 0
+    },
+    {
+      "name": "testSetAggregation",
+      "className": "org.hisp.dhis.analytics.data.AnalyticsServiceTest",
+      "time": 1.4,
+      "status": "passed",
+      "reruns": 0
+    }
+  ]
+}`
+
+	tc := map[string]struct {
+		array  bool
+		suites []TestSuite
+		want   string
+	}{
+		"OneDocument": {
+			suites: []TestSuite{suite},
+			want:   document + "\n",
+		},
+		"ArrayIfSeveralSuites": {
+			suites: []TestSuite{suite, suite, suite},
+			want:   "[\n" + document + ",\n" + document + ",\n" + document + "\n]\n",
+		},
+		"NothingWithoutSuites": {
+			want: "",
+		},
+		"ArrayOfDocuments": {
+			array:  true,
+			suites: []TestSuite{suite, suite},
+			want:   "[\n" + document + ",\n" + document + "\n]\n",
+		},
+		"EmptyArray": {
+			array: true,
+			want:  "[]\n",
+		},
+	}
+
+	for k, v := range tc {
+		t.Run(k, func(t *testing.T) {
+			var w bytes.Buffer
+			enc := &jsonEncoder{w: &w, array: v.array}
+
+			for _, s := range v.suites {
+				if err := enc.encode(s); err != nil {
+					t.Fatalf("expected no error but got %s", err)
+				}
+			}
+			if err := enc.Close(); err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+
+			if diff := cmp.Diff(v.want, w.String()); diff != "" {
+				t.Errorf("encode() mismatch (-want +got): \n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNDJSONEncoder(t *testing.T) {
+	suites, err := decode(strings.NewReader(jsonTestReport), false)
+	if err != nil {
+		t.Fatalf("failed to decode report due to %s", err)
+	}
+	suite := suites[0]
+	suite.Project = &Project{GroupID: "org.hisp.dhis", ArtifactID: "dhis-service-analytics", Path: "dhis-2/dhis-services/dhis-service-analytics"}
+	suite.Plugin = PluginSurefire
+	suite.Archive = "reports.zip"
+
+	var w bytes.Buffer
+	enc := &ndjsonEncoder{w: &w}
+	if err := enc.encode(suite); err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+
+	want := `{"module":"dhis-service-analytics","basedir":"/home/runner/work/dhis2-core/dhis2-core/dhis-2/dhis-services/dhis-service-analytics","project":{"groupId":"org.hisp.dhis","artifactId":"dhis-service-analytics","path":"dhis-2/dhis-services/dhis-service-analytics"},"plugin":"surefire","archive":"reports.zip","suite":{"name":"org.hisp.dhis.analytics.data.AnalyticsServiceTest","time":2.5,"tests":2,"errors":0,"skipped":0,"failures":1},"case":{"name":"testMappingAggregation","className":"org.hisp.dhis.analytics.data.AnalyticsServiceTest","time":1.1,"failure":{"message":"expected: \u003c1\u003e but was: \u003c2\u003e","type":"org.opentest4j.AssertionFailedError","stackTrace":"trace"},"status":"failed","reruns":0}}
+{"module":"dhis-service-analytics","basedir":"/home/runner/work/dhis2-core/dhis2-core/dhis-2/dhis-services/dhis-service-analytics","project":{"groupId":"org.hisp.dhis","artifactId":"dhis-service-analytics","path":"dhis-2/dhis-services/dhis-service-analytics"},"plugin":"surefire","archive":"reports.zip","suite":{"name":"org.hisp.dhis.analytics.data.AnalyticsServiceTest","time":2.5,"tests":2,"errors":0,"skipped":0,"failures":1},"case":{"name":"testSetAggregation","className":"org.hisp.dhis.analytics.data.AnalyticsServiceTest","time":1.4,"status":"passed","reruns":0}}
+`
+	if diff := cmp.Diff(want, w.String()); diff != "" {
+		t.Errorf("encode() mismatch (-want +got): \n%s", diff)
+	}
+}
+
+func TestConverterJSON(t *testing.T) {
+	t.Run("ConcatenatedJSONFile", func(t *testing.T) {
+		var w bytes.Buffer
+		c := Converter{From: "testdata/input", Concat: true, Log: &w, Format: FormatJSON}
+		dest := t.TempDir()
+
+		err := c.To(dest)
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		b, err := os.ReadFile(filepath.Join(dest, "surefire.json"))
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+		var got []jsonSuite
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Fatalf("expected valid JSON but got %s", err)
+		}
+		if len(got) != 2 {
+			t.Fatalf("got %d suites, want 2", len(got))
+		}
+	})
+
+	t.Run("JSONFileOfTestsuitesRoot", func(t *testing.T) {
+		src := t.TempDir()
+		writeReport(t, src, "TEST-merged.xml", `<testsuites>
+  <testsuite name="com.A" time="1" tests="1" errors="0" skipped="0" failures="0">
+    <testcase name="a" classname="com.A" time="1"/>
+  </testsuite>
+  <testsuite name="com.B" time="2" tests="1" errors="0" skipped="0" failures="0">
+    <testcase name="b" classname="com.B" time="2"/>
+  </testsuite>
+</testsuites>`)
+		var w bytes.Buffer
+		c := Converter{From: src, Log: &w, Strict: true, Format: FormatJSON}
+		dest := t.TempDir()
+
+		err := c.To(dest)
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		b, err := os.ReadFile(filepath.Join(dest, "TEST-merged.json"))
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+		var got []jsonSuite
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Fatalf("expected valid JSON but got %s", err)
+		}
+		var names []string
+		for _, s := range got {
+			names = append(names, s.Name)
+		}
+		if diff := cmp.Diff([]string{"com.A", "com.B"}, names); diff != "" {
+			t.Errorf("To() mismatch (-want +got): \n%s", diff)
+		}
+	})
+
+	t.Run("OneNDJSONFilePerXML", func(t *testing.T) {
+		var w bytes.Buffer
+		c := Converter{From: "testdata/input", Log: &w, Format: FormatNDJSON}
+		dest := t.TempDir()
+
+		err := c.To(dest)
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		b, err := os.ReadFile(filepath.Join(dest, "TEST-org.hisp.dhis.analytics.data.AnalyticsServiceTest.ndjson"))
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+		if len(lines) != 4 {
+			t.Fatalf("got %d lines, want one per test case", len(lines))
+		}
+		for _, l := range lines {
+			var got ndjsonRecord
+			if err := json.Unmarshal([]byte(l), &got); err != nil {
+				t.Fatalf("expected valid JSON but got %s", err)
+			}
+		}
+	})
+}
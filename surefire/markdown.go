@@ -0,0 +1,175 @@
+package surefire
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// markdownBudget is the number of characters the Markdown summary is kept
+// under. GitHub rejects comments longer than 65536 characters.
+const markdownBudget = 65536
+
+// markdownReserve is the number of characters kept free of table rows for the
+// text closing the sections.
+const markdownReserve = 1024
+
+// markdownModules is the number of modules listed as the slowest.
+const markdownModules = 10
+
+// markdownMessage is the number of characters failure messages are truncated
+// to.
+const markdownMessage = 200
+
+// markdown collects a Markdown document and counts its characters.
+type markdown struct {
+	b      strings.Builder
+	length int
+}
+
+func (md *markdown) write(s string) {
+	md.b.WriteString(s)
+	md.length += utf8.RuneCountInString(s)
+}
+
+func (md *markdown) line(s string) {
+	md.write(s + "\n")
+}
+
+// row writes the table row if it fits into the budget. It returns false if
+// the row did not fit.
+func (md *markdown) row(cells ...string) bool {
+	var b strings.Builder
+	b.WriteString("|")
+	for _, c := range cells {
+		b.WriteString(" " + c + " |")
+	}
+	b.WriteString("\n")
+
+	if md.length+utf8.RuneCountInString(b.String()) > markdownBudget-markdownReserve {
+		return false
+	}
+	md.write(b.String())
+	return true
+}
+
+// WriteMarkdown writes the totals, the slowest modules and tests and the
+// tests that did not pass as Markdown tables so they can be posted as a pull
+// request comment or a CI job summary. Tests that did not pass are folded
+// into <details> blocks. Rows are left out to keep the document under the
+// 65536 characters GitHub allows in a comment.
+func (s Summary) WriteMarkdown(w io.Writer) error {
+	var md markdown
+
+	total := s.Total()
+	md.line("## Test summary")
+	md.line("")
+	md.line(fmt.Sprintf("**%d** tests, **%d** failures, **%d** errors, **%d** skipped in **%s** seconds",
+		total.Tests, total.Failures, total.Errors, total.Skipped, formatSeconds(total.Time)))
+
+	if len(s.Modules) > 0 {
+		md.line("")
+		md.line("### Slowest modules")
+		md.line("")
+		md.line("| module | tests | failures | errors | skipped | duration [seconds] | p90 [seconds] |")
+		md.line("| --- | ---: | ---: | ---: | ---: | ---: | ---: |")
+		for i, m := range s.Modules {
+			if i == markdownModules || !md.row(
+				markdownCell(m.Module),
+				strconv.Itoa(m.Tests),
+				strconv.Itoa(m.Failures),
+				strconv.Itoa(m.Errors),
+				strconv.Itoa(m.Skipped),
+				formatSeconds(m.Time),
+				formatSeconds(m.P90),
+			) {
+				break
+			}
+		}
+	}
+
+	if len(s.Slowest) > 0 {
+		md.line("")
+		md.line("### Slowest tests")
+		md.line("")
+		md.line("| module | class | test | duration [seconds] |")
+		md.line("| --- | --- | --- | ---: |")
+		for _, t := range s.Slowest {
+			if !md.row(markdownCell(t.Module), markdownCell(t.Class), markdownCell(t.Test), formatSeconds(t.Time)) {
+				break
+			}
+		}
+	}
+
+	var failed, skipped []TestResult
+	for _, t := range s.NotPassed {
+		if t.Status == StatusSkipped {
+			skipped = append(skipped, t)
+		} else {
+			failed = append(failed, t)
+		}
+	}
+	writeMarkdownTests(&md, "Failed tests", failed)
+	writeMarkdownTests(&md, "Skipped tests", skipped)
+
+	_, err := io.WriteString(w, md.b.String())
+	return err
+}
+
+// writeMarkdownTests writes the tests folded into a <details> block with the
+// given title. Tests that do not fit into the budget are counted instead.
+func writeMarkdownTests(md *markdown, title string, tests []TestResult) {
+	if len(tests) == 0 {
+		return
+	}
+
+	md.line("")
+	md.line("<details>")
+	md.line(fmt.Sprintf("<summary>%s (%d)</summary>", title, len(tests)))
+	md.line("")
+	md.line("| status | module | class | test | message |")
+	md.line("| --- | --- | --- | --- | --- |")
+	var written int
+	for _, t := range tests {
+		var message string
+		if t.Result != nil {
+			message = t.Result.Message
+		}
+		if !md.row(string(t.Status), markdownCell(t.Module), markdownCell(t.Class), markdownCell(t.Test), markdownCell(truncate(message, markdownMessage))) {
+			break
+		}
+		written++
+	}
+	if omitted := len(tests) - written; omitted > 0 {
+		md.line("")
+		md.line(fmt.Sprintf("%d more tests are not shown.", omitted))
+	}
+	md.line("")
+	md.line("</details>")
+}
+
+var markdownEscaper = strings.NewReplacer(
+	"|", `\|`,
+	"<", "&lt;",
+	">", "&gt;",
+	"\r\n", " ",
+	"\n", " ",
+	"\r", " ",
+)
+
+// markdownCell escapes s so it can be put into a table cell.
+func markdownCell(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// truncate shortens s to n characters ending in an ellipsis if it is longer.
+func truncate(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,127 @@
+package surefire
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSummaryWriteMarkdown(t *testing.T) {
+	t.Run("Tables", func(t *testing.T) {
+		s := Summary{
+			Modules: []Aggregate{{Module: "dhis-service-analytics", Tests: 3, Failures: 1, Skipped: 1, Time: 3.5, P90: 2}},
+			Slowest: []TestResult{
+				{Module: "dhis-service-analytics", Class: "org.hisp.dhis.AnalyticsTest", Test: "slow", Status: StatusPassed, Time: 2},
+				{Module: "dhis-service-analytics", Class: "org.hisp.dhis.AnalyticsTest", Test: "broken", Status: StatusFailed, Time: 1.5},
+			},
+			NotPassed: []TestResult{
+				{Module: "dhis-service-analytics", Class: "org.hisp.dhis.AnalyticsTest", Test: "broken", Status: StatusFailed, Time: 1.5, Result: &Result{Message: "expected <1>\nbut | was <2>"}},
+				{Module: "dhis-service-analytics", Class: "org.hisp.dhis.AnalyticsTest", Test: "ignored", Status: StatusSkipped, Result: &Result{}},
+			},
+		}
+		var w bytes.Buffer
+
+		err := s.WriteMarkdown(&w)
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		want := `## Test summary
+
+**3** tests, **1** failures, **0** errors, **1** skipped in **3.500** seconds
+
+### Slowest modules
+
+| module | tests | failures | errors | skipped | duration [seconds] | p90 [seconds] |
+| --- | ---: | ---: | ---: | ---: | ---: | ---: |
+| dhis-service-analytics | 3 | 1 | 0 | 1 | 3.500 | 2.000 |
+
+### Slowest tests
+
+| module | class | test | duration [seconds] |
+| --- | --- | --- | ---: |
+| dhis-service-analytics | org.hisp.dhis.AnalyticsTest | slow | 2.000 |
+| dhis-service-analytics | org.hisp.dhis.AnalyticsTest | broken | 1.500 |
+
+<details>
+<summary>Failed tests (1)</summary>
+
+| status | module | class | test | message |
+| --- | --- | --- | --- | --- |
+| failed | dhis-service-analytics | org.hisp.dhis.AnalyticsTest | broken | expected &lt;1&gt; but \| was &lt;2&gt; |
+
+</details>
+
+<details>
+<summary>Skipped tests (1)</summary>
+
+| status | module | class | test | message |
+| --- | --- | --- | --- | --- |
+| skipped | dhis-service-analytics | org.hisp.dhis.AnalyticsTest | ignored |  |
+
+</details>
+`
+		if diff := cmp.Diff(want, w.String()); diff != "" {
+			t.Errorf("WriteMarkdown() mismatch (-want +got): \n%s", diff)
+		}
+	})
+
+	t.Run("StaysWithinBudget", func(t *testing.T) {
+		var s Summary
+		for i := range 2000 {
+			s.NotPassed = append(s.NotPassed, TestResult{
+				Module: "dhis-service-analytics",
+				Class:  "org.hisp.dhis.AnalyticsTest",
+				Test:   "test" + strconv.Itoa(i),
+				Status: StatusFailed,
+				Result: &Result{Message: strings.Repeat("ä", 1000)},
+			})
+		}
+		var w bytes.Buffer
+
+		err := s.WriteMarkdown(&w)
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		got := w.String()
+		if n := utf8.RuneCountInString(got); n > markdownBudget {
+			t.Errorf("expected at most %d characters but got %d", markdownBudget, n)
+		}
+		if !strings.Contains(got, "more tests are not shown.") {
+			t.Error("expected the omitted tests to be counted")
+		}
+		if !strings.HasSuffix(got, "</details>\n") {
+			t.Error("expected the details block to be closed")
+		}
+		if strings.Contains(got, strings.Repeat("ä", markdownMessage)) {
+			t.Error("expected messages to be truncated")
+		}
+	})
+}
+
+func TestTruncate(t *testing.T) {
+	tc := map[string]struct {
+		in   string
+		n    int
+		want string
+	}{
+		"Short":      {in: "expected", n: 10, want: "expected"},
+		"Exact":      {in: "expected", n: 8, want: "expected"},
+		"Long":       {in: "expected 1", n: 5, want: "expe…"},
+		"Multibyte":  {in: "äöüäöü", n: 4, want: "äöü…"},
+		"Whitespace": {in: "  expected\n", n: 8, want: "expected"},
+	}
+
+	for k, v := range tc {
+		t.Run(k, func(t *testing.T) {
+			if got := truncate(v.in, v.n); got != v.want {
+				t.Errorf("truncate(%q, %d) = %q but want %q", v.in, v.n, got, v.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package surefire
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Seconds is a duration in seconds as written by Surefire. Text is the
+// attribute it was decoded from so it can be written unchanged. It is empty
+// if the attribute is missing.
+type Seconds struct {
+	Value float64
+	Text  string
+}
+
+// Duration returns the seconds as time.Duration.
+func (s Seconds) Duration() time.Duration {
+	return time.Duration(s.Value * float64(time.Second))
+}
+
+// String returns the text the seconds were decoded from or the formatted
+// value if they were not decoded.
+func (s Seconds) String() string {
+	if s.Text != "" || s.Value == 0 {
+		return s.Text
+	}
+	return strconv.FormatFloat(s.Value, 'f', -1, 64)
+}
+
+func (s *Seconds) UnmarshalXMLAttr(attr xml.Attr) error {
+	v, err := parseSeconds(attr.Value)
+	if err != nil {
+		return &AttrError{Attr: attr.Name.Local, Value: attr.Value, Err: err}
+	}
+	*s = Seconds{Value: v, Text: attr.Value}
+	return nil
+}
+
+// MarshalJSON encodes the seconds as a number. Seconds are encoded as null
+// if the attribute was missing or is not a number.
+func (s Seconds) MarshalJSON() ([]byte, error) {
+	if s.Text == "" && s.Value == 0 {
+		return []byte("null"), nil
+	}
+	if _, err := parseSeconds(s.Text); err != nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(s.Value)
+}
+
+// UnmarshalJSON decodes seconds encoded as a number, null or as the string
+// they were decoded from.
+func (s *Seconds) UnmarshalJSON(b []byte) error {
+	text, err := jsonNumberText(b)
+	if err != nil {
+		return err
+	}
+	return s.UnmarshalXMLAttr(xml.Attr{Name: xml.Name{Local: "time"}, Value: text})
+}
+
+// Count is a number of tests as written by Surefire. Text is the attribute it
+// was decoded from so it can be written unchanged. It is empty if the
+// attribute is missing.
+type Count struct {
+	Value int
+	Text  string
+}
+
+// String returns the text the count was decoded from or the formatted value
+// if it was not decoded.
+func (c Count) String() string {
+	if c.Text != "" || c.Value == 0 {
+		return c.Text
+	}
+	return strconv.Itoa(c.Value)
+}
+
+func (c *Count) UnmarshalXMLAttr(attr xml.Attr) error {
+	v, err := parseCount(attr.Value)
+	if err != nil {
+		return &AttrError{Attr: attr.Name.Local, Value: attr.Value, Err: err}
+	}
+	*c = Count{Value: v, Text: attr.Value}
+	return nil
+}
+
+// MarshalJSON encodes the count as a number. Counts are encoded as null if
+// the attribute was missing or is not a number.
+func (c Count) MarshalJSON() ([]byte, error) {
+	if c.Text == "" && c.Value == 0 {
+		return []byte("null"), nil
+	}
+	if _, err := parseCount(c.Text); err != nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(c.Value)
+}
+
+// UnmarshalJSON decodes a count encoded as a number, null or as the string
+// it was decoded from.
+func (c *Count) UnmarshalJSON(b []byte) error {
+	text, err := jsonNumberText(b)
+	if err != nil {
+		return err
+	}
+	return c.UnmarshalXMLAttr(xml.Attr{Name: xml.Name{Local: "tests"}, Value: text})
+}
+
+// jsonNumberText returns the text of a JSON number or string. It is empty
+// for null.
+func jsonNumberText(b []byte) (string, error) {
+	var v any
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return "", err
+	}
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case json.Number:
+		return v.String(), nil
+	case string:
+		return v, nil
+	}
+	return "", fmt.Errorf("expected a number but got %s", b)
+}
+
+// AttrError reports an attribute whose value is not a valid number. Line is
+// the line of the element in the report or 0 if it is not known.
+type AttrError struct {
+	Line  int
+	Attr  string
+	Value string
+	Err   error
+}
+
+func (e *AttrError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("invalid %s %q: %s", e.Attr, e.Value, e.Err)
+	}
+	return fmt.Sprintf("line %d: invalid %s %q: %s", e.Line, e.Attr, e.Value, e.Err)
+}
+
+func (e *AttrError) Unwrap() error {
+	return e.Err
+}
+
+// groupSeparators are the characters locales group thousands with.
+const groupSeparators = ",. '\u00a0\u202f"
+
+// grouped matches an integer grouped into thousands like 1,234 or 1 234 567.
+var grouped = regexp.MustCompile(`^\d{1,3}([,. '\x{00a0}\x{202f}]\d{3})+$`)
+
+// parseCount parses a count which may be grouped into thousands. An empty
+// count is zero.
+func parseCount(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	digits, err := integer(s)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(digits)
+}
+
+// parseSeconds parses a duration in seconds. Newer versions of Surefire
+// format durations in English like 1,234.5 while older ones use the default
+// locale so durations like 1.234,5 or 1 234,5 are accepted as well. A single
+// separator followed by three digits like 1,234 is ambiguous and taken as
+// grouping if it is a comma and as decimal separator if it is a dot as that
+// is what Surefire writes in English. An empty duration is zero.
+func parseSeconds(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	number, fraction := s, ""
+	if i := strings.LastIndexAny(s, ".,"); i >= 0 {
+		sep := s[i]
+		number, fraction = s[:i], s[i+1:]
+		other := strings.IndexByte(s[:i], sep) >= 0
+		if other || (sep == ',' && len(fraction) == 3 && number != "0" && !strings.ContainsRune(number, '.')) {
+			// the separator groups thousands as it occurs more than once or
+			// English grouping like 1,234
+			number, fraction = s, ""
+		}
+	}
+
+	digits, err := integer(number)
+	if err != nil {
+		return 0, err
+	}
+	if fraction != "" {
+		if strings.Trim(fraction, "0123456789") != "" {
+			return 0, fmt.Errorf("not a number")
+		}
+		digits += "." + fraction
+	}
+	f, err := strconv.ParseFloat(digits, 64)
+	if err != nil {
+		return 0, fmt.Errorf("not a number")
+	}
+	return f, nil
+}
+
+// integer returns the digits of s which is either a plain or a grouped
+// integer.
+func integer(s string) (string, error) {
+	if s != "" && strings.Trim(s, "0123456789") == "" {
+		return s, nil
+	}
+	if !grouped.MatchString(s) {
+		return "", fmt.Errorf("not a number")
+	}
+	// all group separators must be the same
+	var sep rune
+	digits := strings.Map(func(r rune) rune {
+		if !strings.ContainsRune(groupSeparators, r) {
+			return r
+		}
+		if sep != 0 && sep != r {
+			sep = -1
+		} else if sep == 0 {
+			sep = r
+		}
+		return -1
+	}, s)
+	if sep == -1 {
+		return "", fmt.Errorf("not a number")
+	}
+	return digits, nil
+}
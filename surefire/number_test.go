@@ -0,0 +1,203 @@
+package surefire
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSeconds(t *testing.T) {
+	tc := map[string]struct {
+		in   string
+		want float64
+		err  bool
+	}{
+		"Empty":                          {in: "", want: 0},
+		"Integer":                        {in: "2", want: 2},
+		"Decimal":                        {in: "0.003", want: 0.003},
+		"EnglishGrouping":                {in: "1,234.5", want: 1234.5},
+		"EnglishGroupingWithoutFraction": {in: "1,234", want: 1234},
+		"EnglishGroupingMillions":        {in: "1,234,567.891", want: 1234567.891},
+		"GermanDecimal":                  {in: "0,003", want: 0.003},
+		"GermanDecimalShort":             {in: "12,5", want: 12.5},
+		"GermanGrouping":                 {in: "1.234,5", want: 1234.5},
+		"GermanGroupingMillions":         {in: "1.234.567", want: 1234567},
+		"FrenchGrouping":                 {in: "1 234,5", want: 1234.5},
+		"SwissGrouping":                  {in: "1'234.5", want: 1234.5},
+		"NotANumber":                     {in: "abc", err: true},
+		"Negative":                       {in: "-1", err: true},
+		"Exponent":                       {in: "1e3", err: true},
+		"OnlySeparator":                  {in: ".", err: true},
+		"InvalidGrouping":                {in: "1,23,4", err: true},
+		"MixedGroupSeparators":           {in: "1,234.567.8", err: true},
+	}
+
+	for k, v := range tc {
+		t.Run(k, func(t *testing.T) {
+			got, err := parseSeconds(v.in)
+
+			if v.err {
+				if err == nil {
+					t.Fatalf("expected an error but got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+			if got != v.want {
+				t.Errorf("parseSeconds(%q) = %v but want %v", v.in, got, v.want)
+			}
+		})
+	}
+}
+
+func TestParseCount(t *testing.T) {
+	tc := map[string]struct {
+		in   string
+		want int
+		err  bool
+	}{
+		"Empty":      {in: "", want: 0},
+		"Integer":    {in: "42", want: 42},
+		"Grouped":    {in: "1,234", want: 1234},
+		"Decimal":    {in: "1.5", err: true},
+		"NotANumber": {in: "many", err: true},
+	}
+
+	for k, v := range tc {
+		t.Run(k, func(t *testing.T) {
+			got, err := parseCount(v.in)
+
+			if v.err {
+				if err == nil {
+					t.Fatalf("expected an error but got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+			if got != v.want {
+				t.Errorf("parseCount(%q) = %v but want %v", v.in, got, v.want)
+			}
+		})
+	}
+}
+
+func TestDecodeNumbers(t *testing.T) {
+	t.Run("KeepsText", func(t *testing.T) {
+		suites, err := decode(strings.NewReader(`<testsuite name="a" time="1,234.5" tests="1,001" errors="0" skipped="0" failures="0">
+  <testcase name="b" classname="a" time="0,5"/>
+</testsuite>`), false)
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		suite := suites[0]
+		if got, want := suite.Time, (Seconds{Value: 1234.5, Text: "1,234.5"}); got != want {
+			t.Errorf("got %v but want %v", got, want)
+		}
+		if got, want := suite.Time.Duration(), 1234500*time.Millisecond; got != want {
+			t.Errorf("got %v but want %v", got, want)
+		}
+		if got, want := suite.Tests, (Count{Value: 1001, Text: "1,001"}); got != want {
+			t.Errorf("got %v but want %v", got, want)
+		}
+		if got, want := suite.Cases[0].Time, (Seconds{Value: 0.5, Text: "0,5"}); got != want {
+			t.Errorf("got %v but want %v", got, want)
+		}
+	})
+
+	t.Run("FailsWithLineOfInvalidAttribute", func(t *testing.T) {
+		_, err := decode(strings.NewReader(`<testsuite name="a" time="1" tests="2" errors="0" skipped="0" failures="0">
+  <testcase name="b" classname="a" time="0.5"/>
+  <testcase name="c" classname="a" time="fast"/>
+</testsuite>`), false)
+
+		var ae *AttrError
+		if !errors.As(err, &ae) {
+			t.Fatalf("expected an AttrError but got %v", err)
+		}
+		if want := `line 3: invalid time "fast": not a number`; ae.Error() != want {
+			t.Errorf("got %q but want %q", ae.Error(), want)
+		}
+	})
+
+	t.Run("FailsWithLineOfInvalidSuiteAttribute", func(t *testing.T) {
+		_, err := decode(strings.NewReader(`<testsuites>
+  <testsuite name="a" time="1" tests="two" errors="0" skipped="0" failures="0"/>
+</testsuites>`), false)
+
+		var ae *AttrError
+		if !errors.As(err, &ae) {
+			t.Fatalf("expected an AttrError but got %v", err)
+		}
+		if ae.Line != 2 || ae.Attr != "tests" {
+			t.Errorf("got %q but want error in line 2 for attribute tests", ae.Error())
+		}
+	})
+}
+
+func TestString(t *testing.T) {
+	if got, want := (Seconds{}).String(), ""; got != want {
+		t.Errorf("got %q but want %q for missing seconds", got, want)
+	}
+	if got, want := (Seconds{Value: 1.5}).String(), "1.5"; got != want {
+		t.Errorf("got %q but want %q", got, want)
+	}
+	if got, want := (Count{Value: 3}).String(), "3"; got != want {
+		t.Errorf("got %q but want %q", got, want)
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	tc := map[string]struct {
+		in   any
+		want string
+	}{
+		"Seconds":             {in: Seconds{Value: 1234.5, Text: "1,234.5"}, want: "1234.5"},
+		"SecondsNotDecoded":   {in: Seconds{Value: 1.5}, want: "1.5"},
+		"SecondsZero":         {in: Seconds{Text: "0"}, want: "0"},
+		"SecondsMissing":      {in: Seconds{}, want: "null"},
+		"SecondsInvalid":      {in: Seconds{Text: "fast"}, want: "null"},
+		"Count":               {in: Count{Value: 1234, Text: "1 234"}, want: "1234"},
+		"CountMissing":        {in: Count{}, want: "null"},
+		"CountInvalid":        {in: Count{Text: "many"}, want: "null"},
+		"CountInSuiteMissing": {in: struct{ Tests Count }{}, want: `{"Tests":null}`},
+	}
+
+	for k, v := range tc {
+		t.Run(k, func(t *testing.T) {
+			got, err := json.Marshal(v.in)
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+			if string(got) != v.want {
+				t.Errorf("json.Marshal(%#v) = %s but want %s", v.in, got, v.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	var got struct {
+		Time    Seconds
+		Tests   Count
+		Legacy  Seconds
+		Missing Count
+	}
+	err := json.Unmarshal([]byte(`{"Time":1.5,"Tests":3,"Legacy":"1,234.5","Missing":null}`), &got)
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+
+	if got.Time.Value != 1.5 || got.Tests.Value != 3 || got.Legacy.Value != 1234.5 || got.Missing != (Count{}) {
+		t.Errorf("json.Unmarshal() = %+v", got)
+	}
+	if err := json.Unmarshal([]byte(`{"Time":true}`), &got); err == nil {
+		t.Error("expected an error but got none")
+	}
+}
@@ -0,0 +1,234 @@
+package surefire
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Level is the level test cases are aggregated at by FormatOpenMetrics. Each
+// level has its own metrics so series of different levels never mix.
+type Level string
+
+const (
+	// LevelTest writes the duration of every test case and the number of
+	// tests per class.
+	LevelTest Level = "test"
+	// LevelClass writes the duration and the number of tests per class.
+	LevelClass Level = "class"
+	// LevelModule writes the duration and the number of tests per module.
+	LevelModule Level = "module"
+)
+
+// Levels returns all levels test cases can be aggregated at.
+func Levels() []Level {
+	return []Level{LevelTest, LevelClass, LevelModule}
+}
+
+// metricStatuses are the statuses the number of tests is written for. All of
+// them are written even if no test has the status so series do not vanish.
+var metricStatuses = []Status{StatusPassed, StatusFailed, StatusErrored, StatusSkipped}
+
+// metric describes a gauge and the labels of its series.
+type metric struct {
+	name   string
+	help   string
+	unit   string
+	labels []string
+}
+
+// metricsOf returns the duration and the tests metric of the level.
+func metricsOf(level Level) (duration, tests metric) {
+	switch level {
+	case LevelClass:
+		return metric{name: "surefire_class_duration_seconds", help: "Sum of the durations of the test cases of a class.", unit: "seconds", labels: []string{"module", "class"}},
+			metric{name: "surefire_class_tests_total", help: "Number of test cases of a class by status.", labels: []string{"module", "class", "status"}}
+	case LevelModule:
+		return metric{name: "surefire_module_duration_seconds", help: "Sum of the durations of the test cases of a module.", unit: "seconds", labels: []string{"module"}},
+			metric{name: "surefire_module_tests_total", help: "Number of test cases of a module by status.", labels: []string{"module", "status"}}
+	}
+	return metric{name: "surefire_testcase_duration_seconds", help: "Duration of a test case.", unit: "seconds", labels: []string{"module", "class", "test"}},
+		metric{name: "surefire_suite_tests_total", help: "Number of test cases of a class by status.", labels: []string{"module", "class", "status"}}
+}
+
+// openMetricsEncoder writes gauges of the test cases in the OpenMetrics text
+// format which Prometheus and the textfile collector of node_exporter read.
+// Test cases with the same labels are summed up. The metrics are only
+// written on Close as they aggregate all test suites.
+type openMetricsEncoder struct {
+	w          io.Writer
+	level      Level
+	properties []string
+	durations  map[string]float64
+	tests      map[string]map[Status]int
+}
+
+func newOpenMetricsEncoder(w io.Writer, level Level, properties []string) *openMetricsEncoder {
+	return &openMetricsEncoder{
+		w:          w,
+		level:      level,
+		properties: properties,
+		durations:  map[string]float64{},
+		tests:      map[string]map[Status]int{},
+	}
+}
+
+func (oe *openMetricsEncoder) encode(suite TestSuite) error {
+	for _, c := range suite.Cases {
+		if err := oe.encodeCase(suite, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (oe *openMetricsEncoder) encodeCase(suite TestSuite, c TestCase) error {
+	var properties []string
+	for _, name := range oe.properties {
+		v, _ := suite.Property(name)
+		properties = append(properties, v)
+	}
+
+	values := map[string]string{"module": suite.Module(), "class": c.ClassName, "test": c.Name}
+	duration, tests := metricsOf(oe.level)
+
+	k := oe.key(duration, values, properties)
+	oe.durations[k] += c.Time.Value
+
+	k = oe.key(tests, values, properties)
+	if oe.tests[k] == nil {
+		oe.tests[k] = map[Status]int{}
+	}
+	oe.tests[k][c.Status()]++
+	return nil
+}
+
+// key returns the labels of the series of the metric without the status
+// label. Keys are written as is so they are sorted like the series.
+func (oe *openMetricsEncoder) key(m metric, values map[string]string, properties []string) string {
+	var labels []string
+	for _, l := range m.labels {
+		if l != "status" {
+			labels = append(labels, label(l, values[l]))
+		}
+	}
+	for i, name := range oe.properties {
+		labels = append(labels, label(propertyLabel(name), properties[i]))
+	}
+	return strings.Join(labels, ",")
+}
+
+func (oe *openMetricsEncoder) Close() error {
+	bw := bufio.NewWriter(oe.w)
+	duration, tests := metricsOf(oe.level)
+
+	writeMetricHeader(bw, duration)
+	for _, k := range slices.Sorted(maps.Keys(oe.durations)) {
+		fmt.Fprintf(bw, "%s{%s} %s\n", duration.name, k, formatMetric(oe.durations[k]))
+	}
+	writeMetricHeader(bw, tests)
+	for _, k := range slices.Sorted(maps.Keys(oe.tests)) {
+		for _, s := range metricStatuses {
+			fmt.Fprintf(bw, "%s{%s,%s} %d\n", tests.name, k, label("status", string(s)), oe.tests[k][s])
+		}
+	}
+	fmt.Fprintln(bw, "# EOF")
+	return bw.Flush()
+}
+
+func writeMetricHeader(w io.Writer, m metric) {
+	fmt.Fprintf(w, "# TYPE %s gauge\n", m.name)
+	if m.unit != "" {
+		fmt.Fprintf(w, "# UNIT %s %s\n", m.name, m.unit)
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n", m.name, m.help)
+}
+
+// writeOpenMetricsFailsafeSummaries writes the number of integration tests
+// of every module by their outcome. Summaries of the same module are summed
+// up.
+func writeOpenMetricsFailsafeSummaries(w io.Writer, summaries []FailsafeSummary) error {
+	outcomes := []string{"completed", "errors", "failures", "skipped"}
+	modules := map[string][]int{}
+	for _, s := range summaries {
+		counts := modules[s.Module()]
+		if counts == nil {
+			counts = make([]int, len(outcomes))
+		}
+		for i, c := range []Count{s.Completed, s.Errors, s.Failures, s.Skipped} {
+			counts[i] += c.Value
+		}
+		modules[s.Module()] = counts
+	}
+
+	bw := bufio.NewWriter(w)
+	m := metric{name: "surefire_failsafe_tests_total", help: "Number of integration tests of a module by outcome as summarized by Failsafe."}
+	writeMetricHeader(bw, m)
+	for _, module := range slices.Sorted(maps.Keys(modules)) {
+		for i, outcome := range outcomes {
+			fmt.Fprintf(bw, "%s{%s,%s} %d\n", m.name, label("module", module), label("outcome", outcome), modules[module][i])
+		}
+	}
+	fmt.Fprintln(bw, "# EOF")
+	return bw.Flush()
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// label returns the label with its value escaped.
+func label(name, value string) string {
+	return name + `="` + labelValueEscaper.Replace(value) + `"`
+}
+
+// propertyLabel returns the name of the label of the test suite property.
+// The name is prefixed so it cannot clash with the other labels.
+func propertyLabel(name string) string {
+	return sanitizeLabelName("property_" + name)
+}
+
+// validatePropertyLabels returns an error if properties have the same label
+// like java.version and java_version as their series would have duplicate
+// labels.
+func validatePropertyLabels(properties []string) error {
+	labels := map[string]string{}
+	for _, name := range properties {
+		l := propertyLabel(name)
+		if other, ok := labels[l]; ok {
+			return fmt.Errorf("properties %q and %q have the same label %q", other, name, l)
+		}
+		labels[l] = name
+	}
+	return nil
+}
+
+// sanitizeLabelName replaces all characters that are not allowed in label
+// names by underscores. Label names have to match [a-zA-Z_][a-zA-Z0-9_]*
+// and names starting with __ are reserved.
+func sanitizeLabelName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_', r >= '0' && r <= '9' && i > 0:
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	s := b.String()
+	if strings.HasPrefix(s, "__") {
+		s = "x" + s
+	}
+	if s == "" {
+		s = "_"
+	}
+	return s
+}
+
+// formatMetric formats the value like Prometheus does.
+func formatMetric(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,188 @@
+package surefire
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestConverterOpenMetrics(t *testing.T) {
+	src := t.TempDir()
+	writeProject(t, src, `<project>
+  <groupId>org.hisp.dhis</groupId>
+  <artifactId>dhis-web-api</artifactId>
+</project>`, "")
+	reports := filepath.Join(src, "target", "surefire-reports")
+	writeReport(t, reports, "TEST-org.hisp.dhis.ApiTest.xml", `<testsuite name="org.hisp.dhis.ApiTest" time="3" tests="3" errors="0" skipped="1" failures="1">
+  <properties>
+    <property name="java.version" value="17"/>
+  </properties>
+  <testcase name="passes" classname="org.hisp.dhis.ApiTest" time="1.5"/>
+  <testcase name="fails &quot;quoted&quot;" classname="org.hisp.dhis.ApiTest" time="1">
+    <failure message="expected 1"/>
+  </testcase>
+  <testcase name="skipped" classname="org.hisp.dhis.ApiTest" time="0">
+    <skipped/>
+  </testcase>
+</testsuite>`)
+
+	tc := map[string]struct {
+		level Level
+		want  string
+	}{
+		"Test": {
+			level: LevelTest,
+			want: `# TYPE surefire_testcase_duration_seconds gauge
+# UNIT surefire_testcase_duration_seconds seconds
+# HELP surefire_testcase_duration_seconds Duration of a test case.
+surefire_testcase_duration_seconds{module="dhis-web-api",class="org.hisp.dhis.ApiTest",test="fails \"quoted\"",property_java_version="17"} 1
+surefire_testcase_duration_seconds{module="dhis-web-api",class="org.hisp.dhis.ApiTest",test="passes",property_java_version="17"} 1.5
+surefire_testcase_duration_seconds{module="dhis-web-api",class="org.hisp.dhis.ApiTest",test="skipped",property_java_version="17"} 0
+# TYPE surefire_suite_tests_total gauge
+# HELP surefire_suite_tests_total Number of test cases of a class by status.
+surefire_suite_tests_total{module="dhis-web-api",class="org.hisp.dhis.ApiTest",property_java_version="17",status="passed"} 1
+surefire_suite_tests_total{module="dhis-web-api",class="org.hisp.dhis.ApiTest",property_java_version="17",status="failed"} 1
+surefire_suite_tests_total{module="dhis-web-api",class="org.hisp.dhis.ApiTest",property_java_version="17",status="errored"} 0
+surefire_suite_tests_total{module="dhis-web-api",class="org.hisp.dhis.ApiTest",property_java_version="17",status="skipped"} 1
+# EOF
+`,
+		},
+		"Class": {
+			level: LevelClass,
+			want: `# TYPE surefire_class_duration_seconds gauge
+# UNIT surefire_class_duration_seconds seconds
+# HELP surefire_class_duration_seconds Sum of the durations of the test cases of a class.
+surefire_class_duration_seconds{module="dhis-web-api",class="org.hisp.dhis.ApiTest",property_java_version="17"} 2.5
+# TYPE surefire_class_tests_total gauge
+# HELP surefire_class_tests_total Number of test cases of a class by status.
+surefire_class_tests_total{module="dhis-web-api",class="org.hisp.dhis.ApiTest",property_java_version="17",status="passed"} 1
+surefire_class_tests_total{module="dhis-web-api",class="org.hisp.dhis.ApiTest",property_java_version="17",status="failed"} 1
+surefire_class_tests_total{module="dhis-web-api",class="org.hisp.dhis.ApiTest",property_java_version="17",status="errored"} 0
+surefire_class_tests_total{module="dhis-web-api",class="org.hisp.dhis.ApiTest",property_java_version="17",status="skipped"} 1
+# EOF
+`,
+		},
+		"Module": {
+			level: LevelModule,
+			want: `# TYPE surefire_module_duration_seconds gauge
+# UNIT surefire_module_duration_seconds seconds
+# HELP surefire_module_duration_seconds Sum of the durations of the test cases of a module.
+surefire_module_duration_seconds{module="dhis-web-api",property_java_version="17"} 2.5
+# TYPE surefire_module_tests_total gauge
+# HELP surefire_module_tests_total Number of test cases of a module by status.
+surefire_module_tests_total{module="dhis-web-api",property_java_version="17",status="passed"} 1
+surefire_module_tests_total{module="dhis-web-api",property_java_version="17",status="failed"} 1
+surefire_module_tests_total{module="dhis-web-api",property_java_version="17",status="errored"} 0
+surefire_module_tests_total{module="dhis-web-api",property_java_version="17",status="skipped"} 1
+# EOF
+`,
+		},
+	}
+
+	for k, v := range tc {
+		t.Run(k, func(t *testing.T) {
+			var w bytes.Buffer
+			dest := t.TempDir()
+			c := Converter{From: src, Log: &w, Concat: true, Strict: true, Format: FormatOpenMetrics, Aggregate: v.level, Properties: []string{"java.version"}}
+
+			err := c.To(dest)
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+
+			got, err := os.ReadFile(filepath.Join(dest, "surefire.prom"))
+			if err != nil {
+				t.Fatalf("failed to read metrics due to %s", err)
+			}
+			if diff := cmp.Diff(v.want, string(got)); diff != "" {
+				t.Errorf("To() mismatch (-want +got): \n%s", diff)
+			}
+		})
+	}
+
+	t.Run("AggregatesReportsIntoOneFileWithoutConcat", func(t *testing.T) {
+		writeReport(t, reports, "TEST-org.hisp.dhis.AuthTest.xml", `<testsuite name="org.hisp.dhis.AuthTest" time="2" tests="1" errors="0" skipped="0" failures="0">
+  <testcase name="passes" classname="org.hisp.dhis.AuthTest" time="2"/>
+</testsuite>`)
+		t.Cleanup(func() {
+			os.Remove(filepath.Join(reports, "TEST-org.hisp.dhis.AuthTest.xml"))
+		})
+		var w bytes.Buffer
+		dest := t.TempDir()
+		c := Converter{From: src, Log: &w, Strict: true, Format: FormatOpenMetrics, Aggregate: LevelModule}
+
+		err := c.To(dest)
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		files, err := os.ReadDir(dest)
+		if err != nil {
+			t.Fatalf("failed to read dest due to %s", err)
+		}
+		if len(files) != 1 || files[0].Name() != "surefire.prom" {
+			t.Fatalf("expected only surefire.prom in dest but got %v", files)
+		}
+		got, err := os.ReadFile(filepath.Join(dest, "surefire.prom"))
+		if err != nil {
+			t.Fatalf("failed to read metrics due to %s", err)
+		}
+		want := `# TYPE surefire_module_duration_seconds gauge
+# UNIT surefire_module_duration_seconds seconds
+# HELP surefire_module_duration_seconds Sum of the durations of the test cases of a module.
+surefire_module_duration_seconds{module="dhis-web-api"} 4.5
+# TYPE surefire_module_tests_total gauge
+# HELP surefire_module_tests_total Number of test cases of a module by status.
+surefire_module_tests_total{module="dhis-web-api",status="passed"} 2
+surefire_module_tests_total{module="dhis-web-api",status="failed"} 1
+surefire_module_tests_total{module="dhis-web-api",status="errored"} 0
+surefire_module_tests_total{module="dhis-web-api",status="skipped"} 1
+# EOF
+`
+		if diff := cmp.Diff(want, string(got)); diff != "" {
+			t.Errorf("To() mismatch (-want +got): \n%s", diff)
+		}
+	})
+
+	t.Run("FailsOnPropertiesWithTheSameLabel", func(t *testing.T) {
+		err := Converter{From: src, Format: FormatOpenMetrics, Properties: []string{"java.version", "java_version"}}.To(t.TempDir())
+
+		if err == nil {
+			t.Fatal("expected an error but got none")
+		}
+	})
+
+	t.Run("FailsOnUnknownLevel", func(t *testing.T) {
+		err := Converter{From: src, Format: FormatOpenMetrics, Aggregate: "suite"}.To(t.TempDir())
+
+		if err == nil {
+			t.Fatal("expected an error but got none")
+		}
+	})
+}
+
+func TestSanitizeLabelName(t *testing.T) {
+	tc := map[string]struct {
+		in   string
+		want string
+	}{
+		"Valid":        {in: "java_version", want: "java_version"},
+		"Dots":         {in: "java.version", want: "java_version"},
+		"Dashes":       {in: "surefire-fork", want: "surefire_fork"},
+		"LeadingDigit": {in: "1st", want: "_st"},
+		"Unicode":      {in: "größe", want: "gr__e"},
+		"Reserved":     {in: "__name__", want: "x__name__"},
+		"Empty":        {in: "", want: "_"},
+	}
+
+	for k, v := range tc {
+		t.Run(k, func(t *testing.T) {
+			if got := sanitizeLabelName(v.in); got != v.want {
+				t.Errorf("sanitizeLabelName(%q) = %q but want %q", v.in, got, v.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package surefire
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// outputDir is the directory the output of test cases is written to. It is
+// relative to the directory of the converted reports.
+const outputDir = "output"
+
+// sidecars writes the system-out and system-err of test cases into files
+// named after the module, class and test next to the converted reports.
+// Files of test cases with the same names are numbered with a ~ so they do
+// not overwrite each other. sanitize never keeps a ~ so numbered files cannot
+// collide with the files of test cases whose names end in a number. It is
+// safe to be used by multiple goroutines.
+type sidecars struct {
+	dir string
+	// mu guards used
+	mu   sync.Mutex
+	used map[string]int
+}
+
+func newSidecars(dir string) *sidecars {
+	return &sidecars{dir: dir, used: map[string]int{}}
+}
+
+// output is the system-out or system-err of a test case written to File.
+// File is relative to the directory of the converted reports and empty if
+// the test case did not write any output.
+type output struct {
+	File  string
+	Bytes int
+	Lines int
+}
+
+// write writes the system-out and system-err of test case c into their own
+// files.
+func (s *sidecars) write(suite TestSuite, c TestCase) (stdout, stderr output, err error) {
+	if c.SystemOut == "" && c.SystemErr == "" {
+		return output{}, output{}, nil
+	}
+
+	name := sanitize(c.ClassName) + "." + sanitize(c.Name)
+	if module := suite.Module(); module != "" {
+		name = path.Join(sanitize(module), name)
+	}
+	s.mu.Lock()
+	s.used[name]++
+	n := s.used[name]
+	s.mu.Unlock()
+	if n > 1 {
+		name += "~" + strconv.Itoa(n)
+	}
+
+	stdout, err = s.writeFile(name+"-out.txt", c.SystemOut)
+	if err != nil {
+		return output{}, output{}, err
+	}
+	stderr, err = s.writeFile(name+"-err.txt", c.SystemErr)
+	return stdout, stderr, err
+}
+
+func (s *sidecars) writeFile(name, content string) (output, error) {
+	if content == "" {
+		return output{}, nil
+	}
+
+	file := path.Join(outputDir, name)
+	dst := filepath.Join(s.dir, filepath.FromSlash(file))
+	if err := os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+		return output{}, err
+	}
+	if err := os.WriteFile(dst, []byte(content), 0600); err != nil {
+		return output{}, err
+	}
+	return output{File: file, Bytes: len(content), Lines: lines(content)}, nil
+}
+
+// lines counts the lines in s including a last line that is not terminated
+// by a newline.
+func lines(s string) int {
+	n := strings.Count(s, "\n")
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		n++
+	}
+	return n
+}
+
+// sanitize replaces characters that are not safe to use in file names like
+// the brackets and commas of parameterized test names.
+func sanitize(name string) string {
+	var b bytes.Buffer
+	for _, r := range name {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '.', r == '-', r == '_', r == '$':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
@@ -0,0 +1,178 @@
+package surefire
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestOutput(t *testing.T) {
+	src := t.TempDir()
+	writeReport(t, src, "TEST-org.hisp.dhis.analytics.data.AnalyticsServiceTest.xml", `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="2.5" tests="5" errors="0" skipped="0" failures="0">
+  <properties>
+    <property name="basedir" value="/home/runner/work/dhis2-core/dhis2-core/dhis-2/dhis-services/dhis-service-analytics"/>
+  </properties>
+  <testcase name="testMappingAggregation" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="1.1">
+    <system-out><![CDATA[* INFO  15:32:37,771 Found 7 analytics table types
+* INFO  15:32:37,772 Analytics table update
+]]></system-out>
+    <system-err><![CDATA[* WARN  15:32:37,773 slow]]></system-err>
+  </testcase>
+  <testcase name="testAggregation(String)[1]" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="0.7">
+    <system-out><![CDATA[first]]></system-out>
+  </testcase>
+  <testcase name="testAggregation(String)[1]-2" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="0.2">
+    <system-out><![CDATA[literal]]></system-out>
+  </testcase>
+  <testcase name="testAggregation(String)[1]" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="0.6">
+    <system-out><![CDATA[second]]></system-out>
+  </testcase>
+  <testcase name="testSetAggregation" classname="org.hisp.dhis.analytics.data.AnalyticsServiceTest" time="0.1"/>
+</testsuite>`)
+
+	t.Run("WritesSidecarFiles", func(t *testing.T) {
+		var w bytes.Buffer
+		c := Converter{From: src, Log: &w, Output: true}
+		dest := t.TempDir()
+
+		err := c.To(dest)
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		f, err := os.Open(filepath.Join(dest, "TEST-org.hisp.dhis.analytics.data.AnalyticsServiceTest.csv"))
+		if err != nil {
+			t.Fatalf("failed to open CSV due to %s", err)
+		}
+		defer f.Close()
+		records, err := csv.NewReader(f).ReadAll()
+		if err != nil {
+			t.Fatalf("failed to read CSV due to %s", err)
+		}
+		var got [][]string
+		for _, record := range records {
+			// only compare the test name and output columns
+			got = append(got, append([]string{record[2]}, record[len(record)-6:]...))
+		}
+		want := [][]string{
+			{"test", "test stdout file", "test stdout [bytes]", "test stdout [lines]", "test stderr file", "test stderr [bytes]", "test stderr [lines]"},
+			{"testMappingAggregation", "output/dhis-service-analytics/org.hisp.dhis.analytics.data.AnalyticsServiceTest.testMappingAggregation-out.txt", "95", "2", "output/dhis-service-analytics/org.hisp.dhis.analytics.data.AnalyticsServiceTest.testMappingAggregation-err.txt", "25", "1"},
+			{"testAggregation(String)[1]", "output/dhis-service-analytics/org.hisp.dhis.analytics.data.AnalyticsServiceTest.testAggregation_String__1_-out.txt", "5", "1", "", "0", "0"},
+			{"testAggregation(String)[1]-2", "output/dhis-service-analytics/org.hisp.dhis.analytics.data.AnalyticsServiceTest.testAggregation_String__1_-2-out.txt", "7", "1", "", "0", "0"},
+			{"testAggregation(String)[1]", "output/dhis-service-analytics/org.hisp.dhis.analytics.data.AnalyticsServiceTest.testAggregation_String__1_~2-out.txt", "6", "1", "", "0", "0"},
+			{"testSetAggregation", "", "0", "0", "", "0", "0"},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("To() mismatch (-want +got): \n%s", diff)
+		}
+
+		for _, record := range got[1:] {
+			for _, file := range []string{record[1], record[4]} {
+				if file == "" {
+					continue
+				}
+				if _, err := os.Stat(filepath.Join(dest, filepath.FromSlash(file))); err != nil {
+					t.Errorf("expected output file %q to be written but got %s", file, err)
+				}
+			}
+		}
+		for i, want := range map[int]string{3: "literal", 4: "second"} {
+			b, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(got[i][1])))
+			if err != nil {
+				t.Fatalf("failed to read output file due to %s", err)
+			}
+			if string(b) != want {
+				t.Errorf("got %q but want %q", b, want)
+			}
+		}
+	})
+
+	t.Run("WritesSidecarFilesOfReportsWithTheSameName", func(t *testing.T) {
+		src := t.TempDir()
+		for _, module := range []string{"api", "core"} {
+			dir := filepath.Join(src, module, "target", "surefire-reports")
+			if err := os.MkdirAll(dir, 0750); err != nil {
+				t.Fatalf("failed to create dir due to %s", err)
+			}
+			writeReport(t, dir, "TEST-org.hisp.dhis.ATest.xml", `<testsuite name="org.hisp.dhis.ATest" time="1" tests="1" errors="0" skipped="0" failures="0">
+  <properties>
+    <property name="basedir" value="/home/runner/work/dhis2-core/`+module+`"/>
+  </properties>
+  <testcase name="a" classname="org.hisp.dhis.ATest" time="1">
+    <system-out><![CDATA[`+module+`]]></system-out>
+  </testcase>
+</testsuite>`)
+		}
+		var w bytes.Buffer
+		c := Converter{From: src, Log: &w, Strict: true, Output: true}
+		dest := t.TempDir()
+
+		err := c.To(dest)
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		for _, module := range []string{"api", "core"} {
+			file := filepath.Join(dest, module+"_target_surefire-reports_TEST-org.hisp.dhis.ATest.csv")
+			records := readCSV(t, file)
+			col := -1
+			for i, name := range records[0] {
+				if name == "test stdout file" {
+					col = i
+				}
+			}
+			if col == -1 || len(records) != 2 {
+				t.Fatalf("expected one test with a stdout file column but got %v", records)
+			}
+			// sidecar paths are relative to the directory of their CSV
+			b, err := os.ReadFile(filepath.Join(filepath.Dir(file), filepath.FromSlash(records[1][col])))
+			if err != nil {
+				t.Fatalf("failed to read output file due to %s", err)
+			}
+			if string(b) != module {
+				t.Errorf("got %q but want %q", b, module)
+			}
+		}
+	})
+
+	t.Run("FailsIfFormatIsNotCSV", func(t *testing.T) {
+		var w bytes.Buffer
+		c := Converter{From: src, Log: &w, Output: true, Format: FormatJSON}
+
+		err := c.To(t.TempDir())
+
+		if err == nil {
+			t.Fatal("expected an error but got none")
+		}
+		if want := "output is only supported"; !strings.Contains(err.Error(), want) {
+			t.Fatalf("got %q but want it to contain %q", err, want)
+		}
+	})
+}
+
+func TestLines(t *testing.T) {
+	tc := map[string]struct {
+		in   string
+		want int
+	}{
+		"Empty":                 {in: "", want: 0},
+		"OneLineWithoutNewline": {in: "a", want: 1},
+		"OneLineWithNewline":    {in: "a\n", want: 1},
+		"TwoLines":              {in: "a\nb", want: 2},
+		"EmptyLines":            {in: "\n\n", want: 2},
+	}
+
+	for k, v := range tc {
+		t.Run(k, func(t *testing.T) {
+			if got := lines(v.in); got != v.want {
+				t.Errorf("lines(%q) = %d but want %d", v.in, got, v.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package surefire
+
+import (
+	"io"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// defaultRowGroupSize is the number of rows in a Parquet row group if none is
+// given. Rows are buffered in memory until a row group is full.
+const defaultRowGroupSize = 128 * 1024
+
+// parquetRow is the schema of FormatParquet with one row per test case or
+// attempt of a test case. Columns repeating the same values across many rows
+// are dictionary encoded.
+type parquetRow struct {
+	Module               string            `parquet:"module,dict"`
+	Class                string            `parquet:"class,dict"`
+	Test                 string            `parquet:"test"`
+	DurationSeconds      float64           `parquet:"duration_seconds"`
+	Suite                string            `parquet:"suite,dict"`
+	SuiteDurationSeconds float64           `parquet:"suite_duration_seconds"`
+	SuiteTests           int32             `parquet:"suite_tests"`
+	SuiteErrors          int32             `parquet:"suite_errors"`
+	SuiteSkipped         int32             `parquet:"suite_skipped"`
+	SuiteFailures        int32             `parquet:"suite_failures"`
+	GroupID              string            `parquet:"group_id,optional,dict"`
+	ArtifactID           string            `parquet:"artifact_id,optional,dict"`
+	ModulePath           string            `parquet:"module_path,optional,dict"`
+	Plugin               string            `parquet:"plugin,dict"`
+	Archive              string            `parquet:"archive,optional,dict"`
+	Basedir              string            `parquet:"basedir,optional,dict"`
+	Status               string            `parquet:"status,dict"`
+	FailureType          string            `parquet:"failure_type,optional,dict"`
+	FailureMessage       string            `parquet:"failure_message,optional"`
+	FailureStackTrace    string            `parquet:"failure_stack_trace,optional"`
+	Reruns               int32             `parquet:"reruns"`
+	RerunOutcome         string            `parquet:"rerun_outcome,optional,dict"`
+	Attempt              int32             `parquet:"attempt"`
+	Properties           []parquetProperty `parquet:"properties,optional,list"`
+}
+
+// parquetProperty is a test suite property selected by Converter.Properties.
+type parquetProperty struct {
+	Name  string `parquet:"name,dict"`
+	Value string `parquet:"value"`
+}
+
+// parquetFailsafeSummary is the schema of the Failsafe summaries written in
+// FormatParquet.
+type parquetFailsafeSummary struct {
+	Module         string `parquet:"module,optional,dict"`
+	File           string `parquet:"file"`
+	Result         string `parquet:"result,optional"`
+	Timeout        bool   `parquet:"timeout"`
+	Completed      int32  `parquet:"completed"`
+	Errors         int32  `parquet:"errors"`
+	Failures       int32  `parquet:"failures"`
+	Skipped        int32  `parquet:"skipped"`
+	FailureMessage string `parquet:"failure_message,optional"`
+}
+
+// parquetEncoder writes one Parquet row per test case or attempt of a test
+// case. Rows are written in row groups of at most rowGroupSize rows.
+type parquetEncoder struct {
+	w    *parquet.GenericWriter[parquetRow]
+	opts recordOptions
+}
+
+func newParquetEncoder(w io.Writer, opts recordOptions, rowGroupSize int) *parquetEncoder {
+	if rowGroupSize <= 0 {
+		rowGroupSize = defaultRowGroupSize
+	}
+	return &parquetEncoder{
+		w:    parquet.NewGenericWriter[parquetRow](w, parquet.Compression(&parquet.Snappy), parquet.MaxRowsPerRowGroup(int64(rowGroupSize))),
+		opts: opts,
+	}
+}
+
+func (pe *parquetEncoder) encode(suite TestSuite) error {
+	for _, c := range suite.Cases {
+		if err := pe.encodeCase(suite, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (pe *parquetEncoder) encodeCase(suite TestSuite, c TestCase) error {
+	var properties []parquetProperty
+	for _, name := range pe.opts.properties {
+		if v, ok := suite.Property(name); ok {
+			properties = append(properties, parquetProperty{Name: name, Value: v})
+		}
+	}
+
+	var records []parquetRow
+	for _, r := range rows(suite, c, pe.opts.attempts) {
+		p := r.project()
+		record := parquetRow{
+			Module:               suite.Module(),
+			Class:                c.ClassName,
+			Test:                 c.Name,
+			DurationSeconds:      c.Time.Value,
+			Suite:                suite.Name,
+			SuiteDurationSeconds: suite.Time.Value,
+			SuiteTests:           int32(suite.Tests.Value),
+			SuiteErrors:          int32(suite.Errors.Value),
+			SuiteSkipped:         int32(suite.Skipped.Value),
+			SuiteFailures:        int32(suite.Failures.Value),
+			GroupID:              p.GroupID,
+			ArtifactID:           p.ArtifactID,
+			ModulePath:           p.Path,
+			Plugin:               string(suite.Plugin),
+			Archive:              suite.Archive,
+			Basedir:              suite.Basedir(),
+			Status:               string(r.status),
+			FailureType:          r.failure.Type,
+			FailureMessage:       r.failure.Message,
+			Reruns:               int32(c.Reruns()),
+			RerunOutcome:         string(c.RerunOutcome()),
+			Attempt:              int32(r.attempt),
+			Properties:           properties,
+		}
+		if pe.opts.stackTrace {
+			record.FailureStackTrace = strings.TrimSpace(r.failure.StackTrace)
+		}
+		records = append(records, record)
+	}
+	_, err := pe.w.Write(records)
+	return err
+}
+
+func (pe *parquetEncoder) Close() error {
+	return pe.w.Close()
+}
+
+// writeParquetFailsafeSummaries writes the summaries into w as Parquet.
+func writeParquetFailsafeSummaries(w io.Writer, summaries []FailsafeSummary) error {
+	records := make([]parquetFailsafeSummary, len(summaries))
+	for i, s := range summaries {
+		records[i] = parquetFailsafeSummary{
+			Module:         s.Module(),
+			File:           s.File,
+			Result:         s.Result,
+			Timeout:        s.Timeout,
+			Completed:      int32(s.Completed.Value),
+			Errors:         int32(s.Errors.Value),
+			Failures:       int32(s.Failures.Value),
+			Skipped:        int32(s.Skipped.Value),
+			FailureMessage: s.FailureMessage,
+		}
+	}
+	pw := parquet.NewGenericWriter[parquetFailsafeSummary](w, parquet.Compression(&parquet.Snappy))
+	if _, err := pw.Write(records); err != nil {
+		return err
+	}
+	return pw.Close()
+}
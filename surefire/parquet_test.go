@@ -0,0 +1,137 @@
+package surefire
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/format"
+)
+
+func TestConverterParquet(t *testing.T) {
+	src := t.TempDir()
+	writeProject(t, src, `<project>
+  <groupId>org.hisp.dhis</groupId>
+  <artifactId>dhis-web-api</artifactId>
+</project>`, "")
+	reports := filepath.Join(src, "target", "surefire-reports")
+	writeReport(t, reports, "TEST-org.hisp.dhis.ApiTest.xml", `<testsuite name="org.hisp.dhis.ApiTest" time="1,234.5" tests="2" errors="0" skipped="0" failures="1">
+  <properties>
+    <property name="java.version" value="17.0.2"/>
+    <property name="os.name" value="Linux"/>
+  </properties>
+  <testcase name="passes" classname="org.hisp.dhis.ApiTest" time="1,234.4"/>
+  <testcase name="fails" classname="org.hisp.dhis.ApiTest" time="0.1">
+    <failure message="expected 1" type="org.opentest4j.AssertionFailedError">at org.hisp.dhis.ApiTest.fails(ApiTest.java:42)
+</failure>
+  </testcase>
+</testsuite>`)
+	writeReport(t, reports, "TEST-org.hisp.dhis.WebTest.xml", `<testsuite name="org.hisp.dhis.WebTest" time="2" tests="1" errors="0" skipped="0" failures="0">
+  <testcase name="passes" classname="org.hisp.dhis.WebTest" time="2"/>
+</testsuite>`)
+
+	var w bytes.Buffer
+	dest := t.TempDir()
+	c := Converter{From: src, Log: &w, Concat: true, Strict: true, Format: FormatParquet, StackTrace: true, Properties: []string{"java.*"}, RowGroupSize: 2}
+
+	err := c.To(dest)
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+
+	name := filepath.Join(dest, "surefire.parquet")
+	got, err := parquet.ReadFile[parquetRow](name)
+	if err != nil {
+		t.Fatalf("failed to read Parquet due to %s", err)
+	}
+	suite := parquetRow{
+		Module:               "dhis-web-api",
+		Class:                "org.hisp.dhis.ApiTest",
+		Suite:                "org.hisp.dhis.ApiTest",
+		SuiteDurationSeconds: 1234.5,
+		SuiteTests:           2,
+		SuiteFailures:        1,
+		GroupID:              "org.hisp.dhis",
+		ArtifactID:           "dhis-web-api",
+		Plugin:               "surefire",
+		ModulePath:           ".",
+		Status:               "passed",
+		Attempt:              1,
+		Properties:           []parquetProperty{{Name: "java.version", Value: "17.0.2"}},
+	}
+	passes := suite
+	passes.Test = "passes"
+	passes.DurationSeconds = 1234.4
+	fails := suite
+	fails.Test = "fails"
+	fails.DurationSeconds = 0.1
+	fails.Status = "failed"
+	fails.FailureType = "org.opentest4j.AssertionFailedError"
+	fails.FailureMessage = "expected 1"
+	fails.FailureStackTrace = "at org.hisp.dhis.ApiTest.fails(ApiTest.java:42)"
+	want := []parquetRow{
+		passes,
+		fails,
+		{
+			Module:               "dhis-web-api",
+			Class:                "org.hisp.dhis.WebTest",
+			Test:                 "passes",
+			DurationSeconds:      2,
+			Suite:                "org.hisp.dhis.WebTest",
+			SuiteDurationSeconds: 2,
+			SuiteTests:           1,
+			GroupID:              "org.hisp.dhis",
+			ArtifactID:           "dhis-web-api",
+			ModulePath:           ".",
+			Plugin:               "surefire",
+			Status:               "passed",
+			Attempt:              1,
+		},
+	}
+	// a row without properties is read back with an empty list
+	if diff := cmp.Diff(want, got, cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("To() mismatch (-want +got): \n%s", diff)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("failed to open Parquet due to %s", err)
+	}
+	defer f.Close()
+	s, err := f.Stat()
+	if err != nil {
+		t.Fatalf("failed to stat Parquet due to %s", err)
+	}
+	pf, err := parquet.OpenFile(f, s.Size())
+	if err != nil {
+		t.Fatalf("failed to open Parquet due to %s", err)
+	}
+
+	if got := len(pf.RowGroups()); got != 2 {
+		t.Errorf("expected 2 row groups of at most 2 rows but got %d", got)
+	}
+	for _, column := range []string{"module", "class"} {
+		leaf, ok := pf.Schema().Lookup(column)
+		if !ok {
+			t.Fatalf("expected column %q", column)
+		}
+		meta := pf.Metadata().RowGroups[0].Columns[leaf.ColumnIndex].MetaData
+		if !slices.Contains(meta.Encoding, format.RLEDictionary) {
+			t.Errorf("expected column %q to be dictionary encoded but got %v", column, meta.Encoding)
+		}
+	}
+	for column, want := range map[string]format.Type{"duration_seconds": format.Double, "suite_tests": format.Int32} {
+		leaf, ok := pf.Schema().Lookup(column)
+		if !ok {
+			t.Fatalf("expected column %q", column)
+		}
+		if got := leaf.Node.Type().Kind(); got != parquet.Kind(want) {
+			t.Errorf("expected column %q of type %v but got %v", column, want, got)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package surefire
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Project is the Maven project a report was written by.
+type Project struct {
+	GroupID    string `json:"groupId"`
+	ArtifactID string `json:"artifactId"`
+	// Path is the directory of the project relative to the reactor root
+	// using forward slashes. It is "." for the reactor root itself.
+	Path string `json:"path"`
+}
+
+// pom holds the parts of a pom.xml needed to identify a project.
+type pom struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Parent     struct {
+		GroupID string `xml:"groupId"`
+	} `xml:"parent"`
+}
+
+// projectResolver finds the Maven project of a report by walking up from the
+// report to the nearest directory with a pom.xml. The walk stops at the
+// directory above the target directory the report is in or, if it is not in
+// one, at the walked directory. A pom.xml above it like the one of a checkout
+// CI artifacts are downloaded into does not belong to the reports. The
+// reactor root is the topmost directory of the unbroken chain of directories
+// with a pom.xml above the project. Reports on disk and in archives are
+// resolved within the file system they were found in. It is safe to be used
+// by multiple goroutines.
+type projectResolver struct {
+	// fsys and dir are the file system and the name of the walked directory
+	// on disk.
+	fsys fs.FS
+	dir  string
+
+	// mu guards dirs but is not held while pom.xml files are read so
+	// reports of different modules are resolved in parallel.
+	mu sync.Mutex
+	// dirs caches the pom.xml by file system and directory.
+	dirs map[fsDir]*pomDir
+}
+
+// pomDir caches whether a directory has a pom.xml and the project it
+// defines. Each is looked up once by the first goroutine asking for it while
+// others asking for the same directory wait for it.
+type pomDir struct {
+	stat   sync.Once
+	hasPom bool
+	read   sync.Once
+	resolved
+}
+
+// resolved is a project or the error reading its pom.xml.
+type resolved struct {
+	project *Project
+	err     error
+}
+
+// fsDir is a directory in a file system.
+type fsDir struct {
+	fsys fs.FS
+	dir  string
+}
+
+// newProjectResolver returns a resolver for the reports walked in from. The
+// pom.xml of reports on disk is searched up to from unless they are in a
+// target directory.
+func newProjectResolver(from string) *projectResolver {
+	pr := &projectResolver{dirs: map[fsDir]*pomDir{}}
+	fsys, name, err := diskFS(from)
+	if err != nil {
+		return pr
+	}
+	if s, err := os.Stat(from); err == nil && !s.IsDir() {
+		name = path.Dir(name)
+	}
+	pr.fsys, pr.dir = fsys, name
+	return pr
+}
+
+// resolve returns the project of the report at path on disk. It returns nil
+// if there is no pom.xml in any directory above the report.
+func (pr *projectResolver) resolve(report string) (*Project, error) {
+	fsys, name, err := diskFS(report)
+	if err != nil {
+		return nil, err
+	}
+	return pr.resolveFS(fsys, name)
+}
+
+// resolveFS returns the project of the report name in fsys. It returns nil if
+// there is no pom.xml in any directory above the report. It returns nil and
+// an error if the nearest pom.xml cannot be read or has no artifactId. The
+// module of the report then falls back to its basedir so callers should
+// not treat the error as a failure.
+func (pr *projectResolver) resolveFS(fsys fs.FS, name string) (*Project, error) {
+	top := pr.top(fsys, name)
+	dir := fsDir{fsys: fsys, dir: path.Dir(name)}
+	for !pr.hasPom(dir) {
+		if dir.dir == top || dir.dir == "." {
+			return nil, nil
+		}
+		dir.dir = path.Dir(dir.dir)
+	}
+
+	pd := pr.pomDir(dir)
+	pd.read.Do(func() {
+		pd.resolved = pr.read(dir)
+	})
+	return pd.project, pd.err
+}
+
+// read reads the project of the pom.xml in dir.
+func (pr *projectResolver) read(dir fsDir) resolved {
+	pom, err := readPom(dir.fsys, path.Join(dir.dir, "pom.xml"))
+	if err != nil {
+		return resolved{err: err}
+	}
+	root := dir
+	for parent := (fsDir{fsys: dir.fsys, dir: path.Dir(root.dir)}); root.dir != "." && pr.hasPom(parent); parent.dir = path.Dir(root.dir) {
+		root = parent
+	}
+	rel := "."
+	if root.dir != dir.dir {
+		rel = strings.TrimPrefix(dir.dir, root.dir+"/")
+		if root.dir == "." {
+			rel = dir.dir
+		}
+	}
+
+	p := &Project{GroupID: pom.GroupID, ArtifactID: pom.ArtifactID, Path: rel}
+	// the groupId is inherited from the parent if it is not set
+	if p.GroupID == "" {
+		p.GroupID = pom.Parent.GroupID
+	}
+	return resolved{project: p}
+}
+
+// top returns the topmost directory searched for the pom.xml of the report
+// name in fsys. It is the directory above the nearest target directory the
+// report is in as Maven writes reports into target, otherwise the walked
+// directory if the report is on disk below it.
+func (pr *projectResolver) top(fsys fs.FS, name string) string {
+	for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+		if path.Base(dir) == "target" {
+			return path.Dir(dir)
+		}
+	}
+	if pr.fsys != nil && fsys == pr.fsys && strings.HasPrefix(name, pr.dir+"/") {
+		return pr.dir
+	}
+	return "."
+}
+
+func (pr *projectResolver) hasPom(dir fsDir) bool {
+	pd := pr.pomDir(dir)
+	pd.stat.Do(func() {
+		s, err := fs.Stat(dir.fsys, path.Join(dir.dir, "pom.xml"))
+		pd.hasPom = err == nil && s.Mode().IsRegular()
+	})
+	return pd.hasPom
+}
+
+// pomDir returns the cache of dir creating it if it does not exist.
+func (pr *projectResolver) pomDir(dir fsDir) *pomDir {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	pd, ok := pr.dirs[dir]
+	if !ok {
+		pd = &pomDir{}
+		pr.dirs[dir] = pd
+	}
+	return pd
+}
+
+func readPom(fsys fs.FS, name string) (pom, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return pom{}, err
+	}
+	defer f.Close()
+
+	var p pom
+	if err := xml.NewDecoder(f).Decode(&p); err != nil {
+		return pom{}, fmt.Errorf("failed to decode %q: %w", name, err)
+	}
+	if p.ArtifactID == "" {
+		return pom{}, fmt.Errorf("%q has no artifactId", name)
+	}
+	return p, nil
+}
+
+// diskFS returns the file system of the volume file is on and the name of
+// file in it. File systems of the same volume are equal so they can be used
+// as keys.
+func diskFS(file string) (fs.FS, string, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return nil, "", err
+	}
+	root := filepath.VolumeName(abs) + string(filepath.Separator)
+	return os.DirFS(root), filepath.ToSlash(strings.TrimPrefix(abs, root)), nil
+}
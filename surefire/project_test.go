@@ -0,0 +1,246 @@
+package surefire
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// writeProject writes a pom.xml and a report of a test suite into the
+// surefire-reports of the project in dir.
+func writeProject(t *testing.T, dir, pom, suite string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, "target", "surefire-reports"), 0750); err != nil {
+		t.Fatalf("failed to create project due to %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pom.xml"), []byte(pom), 0600); err != nil {
+		t.Fatalf("failed to write pom.xml due to %s", err)
+	}
+	if suite == "" {
+		return
+	}
+	writeReport(t, filepath.Join(dir, "target", "surefire-reports"), "TEST-"+suite+".xml", `<testsuite name="`+suite+`" time="1" tests="1" errors="0" skipped="0" failures="0">
+  <testcase name="test" classname="`+suite+`" time="1"/>
+</testsuite>`)
+}
+
+func TestProjectResolver(t *testing.T) {
+	root := t.TempDir()
+	writeProject(t, root, `<project>
+  <groupId>org.hisp.dhis</groupId>
+  <artifactId>dhis-root</artifactId>
+</project>`, "")
+	writeProject(t, filepath.Join(root, "dhis-services"), `<project>
+  <parent>
+    <groupId>org.hisp.dhis</groupId>
+    <artifactId>dhis-root</artifactId>
+  </parent>
+  <artifactId>dhis-services</artifactId>
+</project>`, "")
+	writeProject(t, filepath.Join(root, "dhis-services", "core"), `<project>
+  <parent>
+    <groupId>org.hisp.dhis</groupId>
+    <artifactId>dhis-services</artifactId>
+  </parent>
+  <groupId>org.hisp.dhis.services</groupId>
+  <artifactId>dhis-service-core</artifactId>
+</project>`, "org.hisp.dhis.ServiceTest")
+	writeProject(t, filepath.Join(root, "dhis-web", "core"), `<project>
+  <parent>
+    <groupId>org.hisp.dhis</groupId>
+    <artifactId>dhis-root</artifactId>
+  </parent>
+  <artifactId>dhis-web-core</artifactId>
+</project>`, "org.hisp.dhis.WebTest")
+
+	t.Run("Resolve", func(t *testing.T) {
+		tc := map[string]struct {
+			report string
+			want   *Project
+		}{
+			"ProjectWithGroupId": {
+				report: filepath.Join(root, "dhis-services", "core", "target", "surefire-reports", "TEST-org.hisp.dhis.ServiceTest.xml"),
+				want:   &Project{GroupID: "org.hisp.dhis.services", ArtifactID: "dhis-service-core", Path: "dhis-services/core"},
+			},
+			// dhis-web has no pom.xml so the reactor root is dhis-web/core
+			"ProjectInheritingGroupId": {
+				report: filepath.Join(root, "dhis-web", "core", "target", "surefire-reports", "TEST-org.hisp.dhis.WebTest.xml"),
+				want:   &Project{GroupID: "org.hisp.dhis", ArtifactID: "dhis-web-core", Path: "."},
+			},
+			"ReactorRoot": {
+				report: filepath.Join(root, "target", "surefire-reports", "TEST-org.hisp.dhis.RootTest.xml"),
+				want:   &Project{GroupID: "org.hisp.dhis", ArtifactID: "dhis-root", Path: "."},
+			},
+			"NoProject": {
+				report: filepath.Join(t.TempDir(), "TEST-org.hisp.dhis.RootTest.xml"),
+			},
+		}
+
+		pr := newProjectResolver(root)
+		for k, v := range tc {
+			t.Run(k, func(t *testing.T) {
+				got, err := pr.resolve(v.report)
+				if err != nil {
+					t.Fatalf("expected no error but got %s", err)
+				}
+				if diff := cmp.Diff(v.want, got); diff != "" {
+					t.Errorf("resolve() mismatch (-want +got): \n%s", diff)
+				}
+			})
+		}
+	})
+
+	t.Run("FallsBackToBasedirOnBrokenPom", func(t *testing.T) {
+		tc := map[string]string{
+			"Malformed":         `<project><artifactId>dhis-broken`,
+			"WithoutArtifactId": `<project><groupId>org.hisp.dhis</groupId></project>`,
+		}
+
+		for k, pom := range tc {
+			t.Run(k, func(t *testing.T) {
+				dir := filepath.Join(t.TempDir(), "dhis-service-broken")
+				writeProject(t, dir, pom, "")
+				writeReport(t, filepath.Join(dir, "target", "surefire-reports"), "TEST-org.hisp.dhis.Test.xml", `<testsuite name="org.hisp.dhis.Test" time="1" tests="1" errors="0" skipped="0" failures="0">
+  <properties>
+    <property name="basedir" value="`+dir+`"/>
+  </properties>
+  <testcase name="test" classname="org.hisp.dhis.Test" time="1"/>
+</testsuite>`)
+				var w bytes.Buffer
+				dest := t.TempDir()
+				c := Converter{From: dir, Log: &w, Debug: true, Concat: true, Strict: true, Columns: []string{"module", "artifact-id", "class"}}
+
+				err := c.To(dest)
+				if err != nil {
+					t.Fatalf("expected no error but got %s", err)
+				}
+
+				got, err := os.ReadFile(filepath.Join(dest, "surefire.csv"))
+				if err != nil {
+					t.Fatalf("failed to read CSV due to %s", err)
+				}
+				want := "module,artifactId,class\ndhis-service-broken,,org.hisp.dhis.Test\n"
+				if diff := cmp.Diff(want, string(got)); diff != "" {
+					t.Errorf("To() mismatch (-want +got): \n%s", diff)
+				}
+				if !strings.Contains(w.String(), "Ignored pom.xml of") {
+					t.Errorf("expected the pom.xml to be logged but got %q", w.String())
+				}
+			})
+		}
+	})
+
+	t.Run("ConvertWritesProjectColumns", func(t *testing.T) {
+		var w bytes.Buffer
+		c := Converter{From: root, Log: &w, Concat: true, Columns: []string{"module", "group-id", "artifact-id", "module-path", "class"}}
+		dest := t.TempDir()
+
+		err := c.To(dest)
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		f, err := os.Open(filepath.Join(dest, "surefire.csv"))
+		if err != nil {
+			t.Fatalf("failed to open CSV due to %s", err)
+		}
+		defer f.Close()
+		got, err := csv.NewReader(f).ReadAll()
+		if err != nil {
+			t.Fatalf("failed to read CSV due to %s", err)
+		}
+		want := [][]string{
+			{"module", "groupId", "artifactId", "module path", "class"},
+			{"dhis-service-core", "org.hisp.dhis.services", "dhis-service-core", "dhis-services/core", "org.hisp.dhis.ServiceTest"},
+			{"dhis-web-core", "org.hisp.dhis", "dhis-web-core", ".", "org.hisp.dhis.WebTest"},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("To() mismatch (-want +got): \n%s", diff)
+		}
+	})
+
+	t.Run("ConvertWritesProjectColumnsByDefault", func(t *testing.T) {
+		var w bytes.Buffer
+		c := Converter{From: root, Log: &w, Concat: true}
+		dest := t.TempDir()
+
+		err := c.To(dest)
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		records := readCSV(t, filepath.Join(dest, "surefire.csv"))
+		var got [][]string
+		for _, r := range records {
+			got = append(got, r[len(r)-3:])
+		}
+		want := [][]string{
+			{"groupId", "artifactId", "module path"},
+			{"org.hisp.dhis.services", "dhis-service-core", "dhis-services/core"},
+			{"org.hisp.dhis", "dhis-web-core", "."},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("To() mismatch (-want +got): \n%s", diff)
+		}
+	})
+
+	t.Run("IgnoresPomAboveFrom", func(t *testing.T) {
+		checkout := t.TempDir()
+		writeProject(t, checkout, `<project>
+  <groupId>org.hisp.dhis</groupId>
+  <artifactId>root</artifactId>
+</project>`, "")
+		reports := filepath.Join(checkout, "ci-artifacts", "surefire-reports")
+		if err := os.MkdirAll(reports, 0750); err != nil {
+			t.Fatalf("failed to create reports due to %s", err)
+		}
+		writeReport(t, reports, "TEST-org.hisp.dhis.ServiceTest.xml", `<testsuite name="org.hisp.dhis.ServiceTest" time="1" tests="1" errors="0" skipped="0" failures="0">
+  <properties>
+    <property name="basedir" value="/home/runner/work/dhis2-core/dhis2-core/dhis-2/dhis-services/dhis-service-core"/>
+  </properties>
+  <testcase name="test" classname="org.hisp.dhis.ServiceTest" time="1"/>
+</testsuite>`)
+		var w bytes.Buffer
+		c := Converter{From: filepath.Join(checkout, "ci-artifacts"), Log: &w, Concat: true, Strict: true, Columns: []string{"module", "artifact-id", "class"}}
+		dest := t.TempDir()
+
+		err := c.To(dest)
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		got := readCSV(t, filepath.Join(dest, "surefire.csv"))
+		want := [][]string{
+			{"module", "artifactId", "class"},
+			{"dhis-service-core", "", "org.hisp.dhis.ServiceTest"},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("To() mismatch (-want +got): \n%s", diff)
+		}
+	})
+
+	t.Run("FindsPomAboveFromInTarget", func(t *testing.T) {
+		var w bytes.Buffer
+		c := Converter{From: filepath.Join(root, "dhis-services", "core", "target", "surefire-reports"), Log: &w, Concat: true, Strict: true, Columns: []string{"module", "class"}}
+		dest := t.TempDir()
+
+		err := c.To(dest)
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		got := readCSV(t, filepath.Join(dest, "surefire.csv"))
+		want := [][]string{
+			{"module", "class"},
+			{"dhis-service-core", "org.hisp.dhis.ServiceTest"},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("To() mismatch (-want +got): \n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package surefire
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// propertyNames expands the glob patterns in names into the names of the
+// properties found in the reports in from. Names without any glob characters
+// are kept as is even if no report has them. Names are returned in the order
+// of the patterns with the ones matching a pattern sorted by name. Reports
+// are only read if there is a pattern and only the ones selected by filter.
+func propertyNames(from string, filter fileFilter, names []string) ([]string, error) {
+	var found []string
+	for _, name := range names {
+		if _, err := path.Match(name, ""); err != nil {
+			return nil, fmt.Errorf("invalid property pattern %q: %w", name, err)
+		}
+		if isGlob(name) && found == nil {
+			var err error
+			found, err = properties(from, filter)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var expanded []string
+	seen := map[string]bool{}
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			expanded = append(expanded, name)
+		}
+	}
+	for _, name := range names {
+		if !isGlob(name) {
+			add(name)
+			continue
+		}
+		for _, f := range found {
+			// the pattern is valid as it has been matched before
+			if ok, _ := path.Match(name, f); ok {
+				add(f)
+			}
+		}
+	}
+	return expanded, nil
+}
+
+func isGlob(name string) bool {
+	return strings.ContainsAny(name, `*?[\`)
+}
+
+// properties returns the sorted names of all properties in the reports in
+// from selected by filter. Reports that cannot be read are skipped as they
+// are reported when they are converted.
+func properties(from string, filter fileFilter) ([]string, error) {
+	found, _, err := reports(from, io.Discard, filter, false)
+	defer found.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	names := []string{}
+	for _, rf := range found.files {
+		suites, err := decodeProperties(rf)
+		rf.done()
+		if err != nil {
+			continue
+		}
+		for _, suite := range suites {
+			for _, prop := range suite.Properties.Properties {
+				if !seen[prop.Name] {
+					seen[prop.Name] = true
+					names = append(names, prop.Name)
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// decodeProperties decodes the suites in the report rf without their test
+// cases.
+func decodeProperties(rf reportFile) ([]TestSuite, error) {
+	r, err := rf.fsys.Open(rf.name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return decodeCases(r, false, func(*TestSuite, TestCase) error { return nil })
+}
@@ -0,0 +1,77 @@
+package surefire
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPropertyNames(t *testing.T) {
+	src := t.TempDir()
+	writeReport(t, src, "TEST-a.xml", `<testsuite name="a" time="1" tests="0" errors="0" skipped="0" failures="0">
+  <properties>
+    <property name="java.version" value="11.0.13"/>
+    <property name="java.vendor" value="Azul Systems, Inc."/>
+    <property name="os.name" value="Linux"/>
+  </properties>
+</testsuite>`)
+	writeReport(t, src, "TEST-b.xml", `<testsuites>
+  <testsuite name="b" time="1" tests="0" errors="0" skipped="0" failures="0">
+    <properties>
+      <property name="java.home" value="/usr/lib/jvm/zulu11"/>
+      <property name="user.timezone" value="Etc/UTC"/>
+    </properties>
+  </testsuite>
+</testsuites>`)
+
+	tc := map[string]struct {
+		from  string
+		names []string
+		want  []string
+		err   bool
+	}{
+		"NamesAreKept": {
+			from:  "testdata/missing_src_directory/",
+			names: []string{"os.name", "missing"},
+			want:  []string{"os.name", "missing"},
+		},
+		"PatternsAreExpandedInOrder": {
+			from:  src,
+			names: []string{"user.timezone", "java.*", "os.name"},
+			want:  []string{"user.timezone", "java.home", "java.vendor", "java.version", "os.name"},
+		},
+		"DuplicatesAreRemoved": {
+			from:  src,
+			names: []string{"java.version", "java.*"},
+			want:  []string{"java.version", "java.home", "java.vendor"},
+		},
+		"PatternMatchingNothing": {
+			from:  src,
+			names: []string{"maven.*"},
+		},
+		"InvalidPattern": {
+			from:  src,
+			names: []string{"java.["},
+			err:   true,
+		},
+	}
+
+	for k, v := range tc {
+		t.Run(k, func(t *testing.T) {
+			got, err := propertyNames(v.from, fileFilter{}, v.names)
+
+			if v.err {
+				if err == nil {
+					t.Fatal("expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+			if diff := cmp.Diff(v.want, got); diff != "" {
+				t.Errorf("propertyNames() mismatch (-want +got): \n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package surefire
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Sharder partitions the test classes found in the Maven Surefire XML reports
+// in From into Shards shards that take about the same time to run. Shards must
+// not exceed the number of test classes so no shard is empty.
+type Sharder struct {
+	From string
+	// Include, Exclude and FollowSymlinks select the reports like they do
+	// in Converter.
+	Include        []string
+	Exclude        []string
+	FollowSymlinks bool
+	Log            io.Writer
+	Debug          bool
+	Shards         int
+}
+
+// Plan holds the shards ordered by their index.
+type Plan struct {
+	Shards []Shard `json:"shards"`
+}
+
+// Shard is a set of test classes to be run together.
+type Shard struct {
+	// Time is the expected duration of the shard in seconds.
+	Time    float64     `json:"time"`
+	Classes []ClassTime `json:"classes"`
+}
+
+// ClassTime is the duration of a test class in seconds. The duration of a
+// class is the duration of its test suite so it includes setup and teardown.
+// A class found in more than one module is planned as one class with the
+// summed duration of all modules as Surefire runs it in every module of the
+// shard it is in. Its Module is empty then.
+type ClassTime struct {
+	Module string  `json:"module"`
+	Class  string  `json:"class"`
+	Time   float64 `json:"time"`
+}
+
+func (s Sharder) Plan() (Plan, error) {
+	if s.Shards < 1 {
+		return Plan{}, fmt.Errorf("number of shards must be at least 1 but is %d", s.Shards)
+	}
+
+	filter := fileFilter{include: s.Include, exclude: s.Exclude, followSymlinks: s.FollowSymlinks}
+	if err := filter.validate(); err != nil {
+		return Plan{}, err
+	}
+
+	times := map[string]float64{}
+	modules := map[string]string{}
+	_, err := walk(s.From, s.Log, s.Debug, walkOptions{filter: filter}, func(path string, suites []TestSuite) error {
+		for _, suite := range suites {
+			// suites wrapping nested suites have no test cases of their own
+			// and would be scheduled with the time of the nested suites
+			if len(suite.Cases) == 0 {
+				continue
+			}
+			module, ok := modules[suite.Name]
+			if !ok {
+				modules[suite.Name] = suite.Module()
+			} else if module != suite.Module() {
+				modules[suite.Name] = ""
+			}
+			times[suite.Name] += suite.Time.Value
+		}
+		return nil
+	})
+	if err != nil {
+		return Plan{}, err
+	}
+
+	// an empty shard would pass an empty -Dtest= and run all tests
+	if s.Shards > len(times) {
+		return Plan{}, fmt.Errorf("number of shards %d exceeds the number of test classes %d found in %q", s.Shards, len(times), s.From)
+	}
+
+	classes := make([]ClassTime, 0, len(times))
+	for class, t := range times {
+		classes = append(classes, ClassTime{Module: modules[class], Class: class, Time: t})
+	}
+	return Partition(classes, s.Shards), nil
+}
+
+// Partition partitions the classes into n shards using the longest processing
+// time first algorithm. Classes are assigned to the shard with the smallest
+// time starting with the slowest class. Shards are left empty if there are
+// fewer classes than shards which is why Plan rejects that as an empty shard
+// runs all tests when passed to -Dtest=.
+func Partition(classes []ClassTime, n int) Plan {
+	sorted := make([]ClassTime, len(classes))
+	copy(sorted, classes)
+	// sort by name on equal times so the plan is stable across runs
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.Time != b.Time {
+			return a.Time > b.Time
+		}
+		if a.Class != b.Class {
+			return a.Class < b.Class
+		}
+		return a.Module < b.Module
+	})
+
+	plan := Plan{Shards: make([]Shard, n)}
+	for _, c := range sorted {
+		smallest := 0
+		for i, s := range plan.Shards {
+			if s.Time < plan.Shards[smallest].Time {
+				smallest = i
+			}
+		}
+		plan.Shards[smallest].Time += c.Time
+		plan.Shards[smallest].Classes = append(plan.Shards[smallest].Classes, c)
+	}
+	for i := range plan.Shards {
+		if plan.Shards[i].Classes == nil {
+			plan.Shards[i].Classes = []ClassTime{}
+		}
+	}
+
+	return plan
+}
+
+// Test returns the test classes of the shard as value for Surefire's test
+// parameter like in -Dtest=.
+func (s Shard) Test() string {
+	classes := make([]string, len(s.Classes))
+	for i, c := range s.Classes {
+		classes[i] = c.Class
+	}
+	return strings.Join(classes, ",")
+}
+
+// WriteIncludes writes the test classes of the shard in the format of
+// Surefire's includesFile with one pattern per line. Nested classes are
+// written as the source file of their outer class which is written once.
+func (s Shard) WriteIncludes(w io.Writer) error {
+	seen := map[string]bool{}
+	for _, c := range s.Classes {
+		class, _, _ := strings.Cut(c.Class, "$")
+		if seen[class] {
+			continue
+		}
+		seen[class] = true
+		_, err := fmt.Fprintln(w, strings.ReplaceAll(class, ".", "/")+".java")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteTable writes the expected time, the number of classes and the test
+// parameter of every shard.
+func (p Plan) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "shard\ttime [seconds]\tclasses [number]\ttest")
+	for i, s := range p.Shards {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\n", i+1, formatSeconds(s.Time), len(s.Classes), s.Test())
+	}
+	return tw.Flush()
+}
+
+// WriteTests writes the test parameter of every shard on its own line.
+func (p Plan) WriteTests(w io.Writer) error {
+	for _, s := range p.Shards {
+		if _, err := fmt.Fprintln(w, s.Test()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p Plan) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// WriteIncludes writes one includesFile per shard named shard-<index>.txt
+// into dir. dir is created if it does not exist.
+func (p Plan) WriteIncludes(dir string) error {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+	for i, s := range p.Shards {
+		err := writeFile(filepath.Join(dir, "shard-"+strconv.Itoa(i+1)+".txt"), s.WriteIncludes)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
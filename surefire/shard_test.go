@@ -0,0 +1,242 @@
+package surefire
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPartition(t *testing.T) {
+	tc := map[string]struct {
+		classes []ClassTime
+		n       int
+		want    Plan
+	}{
+		"OneShard": {
+			classes: []ClassTime{{Class: "a.ATest", Time: 1}, {Class: "a.BTest", Time: 2}},
+			n:       1,
+			want: Plan{Shards: []Shard{
+				{Time: 3, Classes: []ClassTime{{Class: "a.BTest", Time: 2}, {Class: "a.ATest", Time: 1}}},
+			}},
+		},
+		"LongestFirstIntoShardWithLeastTime": {
+			classes: []ClassTime{
+				{Class: "a.ATest", Time: 5},
+				{Class: "a.BTest", Time: 4},
+				{Class: "a.CTest", Time: 3},
+				{Class: "a.DTest", Time: 3},
+				{Class: "a.ETest", Time: 3},
+			},
+			n: 2,
+			want: Plan{Shards: []Shard{
+				{Time: 8, Classes: []ClassTime{{Class: "a.ATest", Time: 5}, {Class: "a.DTest", Time: 3}}},
+				{Time: 10, Classes: []ClassTime{{Class: "a.BTest", Time: 4}, {Class: "a.CTest", Time: 3}, {Class: "a.ETest", Time: 3}}},
+			}},
+		},
+		"MoreShardsThanClasses": {
+			classes: []ClassTime{{Class: "a.ATest", Time: 1}},
+			n:       2,
+			want: Plan{Shards: []Shard{
+				{Time: 1, Classes: []ClassTime{{Class: "a.ATest", Time: 1}}},
+				{Time: 0, Classes: []ClassTime{}},
+			}},
+		},
+	}
+
+	for k, v := range tc {
+		t.Run(k, func(t *testing.T) {
+			got := Partition(v.classes, v.n)
+
+			if diff := cmp.Diff(v.want, got); diff != "" {
+				t.Errorf("Partition() mismatch (-want +got): \n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSharder(t *testing.T) {
+	t.Run("Plan", func(t *testing.T) {
+		var w bytes.Buffer
+		s := Sharder{From: "testdata/input", Log: &w, Shards: 2}
+
+		got, err := s.Plan()
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		want := Plan{Shards: []Shard{
+			{Time: 171.217, Classes: []ClassTime{{Module: "dhis-service-analytics", Class: "org.hisp.dhis.analytics.data.AnalyticsServiceTest", Time: 171.217}}},
+			{Time: 0.003, Classes: []ClassTime{{Module: "dhis-service-administration", Class: "org.hisp.dhis.maintenance.HardDeleteAuditTest", Time: 0.003}}},
+		}}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Plan() mismatch (-want +got): \n%s", diff)
+		}
+	})
+
+	t.Run("PlanSkipsSuitesWrappingNestedSuites", func(t *testing.T) {
+		src := t.TempDir()
+		writeReport(t, src, "TEST-Outer.xml", `<testsuite name="Outer" time="10" tests="2" errors="0" skipped="0" failures="0">
+  <testsuite name="com.A" time="4" tests="1" errors="0" skipped="0" failures="0">
+    <testcase name="a" classname="com.A" time="3"/>
+  </testsuite>
+  <testsuite name="com.B" time="6" tests="1" errors="0" skipped="0" failures="0">
+    <testcase name="b" classname="com.B" time="6"/>
+  </testsuite>
+</testsuite>`)
+		var w bytes.Buffer
+		s := Sharder{From: src, Log: &w, Shards: 2}
+
+		got, err := s.Plan()
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		want := Plan{Shards: []Shard{
+			{Time: 6, Classes: []ClassTime{{Class: "com.B", Time: 6}}},
+			{Time: 4, Classes: []ClassTime{{Class: "com.A", Time: 4}}},
+		}}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Plan() mismatch (-want +got): \n%s", diff)
+		}
+	})
+
+	t.Run("PlanMergesClassesInSeveralModules", func(t *testing.T) {
+		src := t.TempDir()
+		for module, time := range map[string]string{"core": "3", "web": "4"} {
+			writeReport(t, src, "TEST-com.A-"+module+".xml", `<testsuite name="com.A" time="`+time+`" tests="1" errors="0" skipped="0" failures="0">
+  <properties>
+    <property name="basedir" value="/home/runner/work/`+module+`"/>
+  </properties>
+  <testcase name="a" classname="com.A" time="`+time+`"/>
+</testsuite>`)
+		}
+		writeReport(t, src, "TEST-com.B.xml", `<testsuite name="com.B" time="5" tests="1" errors="0" skipped="0" failures="0">
+  <properties>
+    <property name="basedir" value="/home/runner/work/core"/>
+  </properties>
+  <testcase name="b" classname="com.B" time="5"/>
+</testsuite>`)
+		var w bytes.Buffer
+		s := Sharder{From: src, Log: &w, Shards: 2}
+
+		got, err := s.Plan()
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		want := Plan{Shards: []Shard{
+			{Time: 7, Classes: []ClassTime{{Class: "com.A", Time: 7}}},
+			{Time: 5, Classes: []ClassTime{{Module: "core", Class: "com.B", Time: 5}}},
+		}}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Plan() mismatch (-want +got): \n%s", diff)
+		}
+	})
+
+	t.Run("FailsIfShardsExceedClasses", func(t *testing.T) {
+		var w bytes.Buffer
+		s := Sharder{From: "testdata/input", Log: &w, Shards: 3}
+
+		_, err := s.Plan()
+
+		if err == nil {
+			t.Fatal("expected an error but got none")
+		}
+		want := "number of shards 3 exceeds the number of test classes 2"
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("got error %q but want %q", err, want)
+		}
+	})
+
+	t.Run("PlanOnlyIncludesSelectedReports", func(t *testing.T) {
+		var w bytes.Buffer
+		s := Sharder{From: "testdata/input", Log: &w, Shards: 1, Include: []string{"*AnalyticsServiceTest.xml"}}
+
+		got, err := s.Plan()
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		want := Plan{Shards: []Shard{
+			{Time: 171.217, Classes: []ClassTime{{Module: "dhis-service-analytics", Class: "org.hisp.dhis.analytics.data.AnalyticsServiceTest", Time: 171.217}}},
+		}}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Plan() mismatch (-want +got): \n%s", diff)
+		}
+	})
+
+	t.Run("FailsIfShardsIsLessThanOne", func(t *testing.T) {
+		var w bytes.Buffer
+		s := Sharder{From: "testdata/input", Log: &w}
+
+		_, err := s.Plan()
+
+		if err == nil {
+			t.Fatal("expected an error but got none")
+		}
+	})
+}
+
+func TestPlanWrite(t *testing.T) {
+	p := Plan{Shards: []Shard{
+		{Time: 3, Classes: []ClassTime{{Class: "org.hisp.dhis.ATest", Time: 2}, {Class: "org.hisp.dhis.BTest", Time: 1}}},
+		{Time: 2, Classes: []ClassTime{{Class: "org.hisp.dhis.CTest", Time: 2}}},
+	}}
+
+	t.Run("Tests", func(t *testing.T) {
+		var w bytes.Buffer
+
+		if err := p.WriteTests(&w); err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		want := "org.hisp.dhis.ATest,org.hisp.dhis.BTest\norg.hisp.dhis.CTest\n"
+		if diff := cmp.Diff(want, w.String()); diff != "" {
+			t.Errorf("WriteTests() mismatch (-want +got): \n%s", diff)
+		}
+	})
+
+	t.Run("Includes", func(t *testing.T) {
+		dest := filepath.Join(t.TempDir(), "shards")
+
+		if err := p.WriteIncludes(dest); err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		for name, want := range map[string]string{
+			"shard-1.txt": "org/hisp/dhis/ATest.java\norg/hisp/dhis/BTest.java\n",
+			"shard-2.txt": "org/hisp/dhis/CTest.java\n",
+		} {
+			got, err := os.ReadFile(filepath.Join(dest, name))
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+			if diff := cmp.Diff(want, string(got)); diff != "" {
+				t.Errorf("WriteIncludes() %s mismatch (-want +got): \n%s", name, diff)
+			}
+		}
+	})
+
+	t.Run("IncludesOuterClassOfNestedClassesOnce", func(t *testing.T) {
+		s := Shard{Classes: []ClassTime{
+			{Class: "org.hisp.dhis.ATest$Nested"},
+			{Class: "org.hisp.dhis.BTest"},
+			{Class: "org.hisp.dhis.ATest$Nested$Deeper"},
+			{Class: "org.hisp.dhis.ATest"},
+		}}
+		var w bytes.Buffer
+
+		if err := s.WriteIncludes(&w); err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		want := "org/hisp/dhis/ATest.java\norg/hisp/dhis/BTest.java\n"
+		if diff := cmp.Diff(want, w.String()); diff != "" {
+			t.Errorf("WriteIncludes() mismatch (-want +got): \n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,259 @@
+package surefire
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	// registers the pure Go sqlite driver so sure can be cross-compiled
+	_ "modernc.org/sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	created_at TEXT NOT NULL,
+	source TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS suites (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	file TEXT NOT NULL,
+	module TEXT NOT NULL,
+	basedir TEXT NOT NULL,
+	group_id TEXT,
+	artifact_id TEXT,
+	module_path TEXT,
+	plugin TEXT,
+	archive TEXT,
+	name TEXT NOT NULL,
+	time REAL,
+	tests INTEGER,
+	errors INTEGER,
+	skipped INTEGER,
+	failures INTEGER
+);
+CREATE TABLE IF NOT EXISTS properties (
+	suite_id INTEGER NOT NULL REFERENCES suites(id),
+	name TEXT NOT NULL,
+	value TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS cases (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	suite_id INTEGER NOT NULL REFERENCES suites(id),
+	class TEXT NOT NULL,
+	name TEXT NOT NULL,
+	time REAL,
+	status TEXT NOT NULL,
+	failure_type TEXT,
+	failure_message TEXT,
+	failure_stack_trace TEXT,
+	reruns INTEGER NOT NULL,
+	rerun_outcome TEXT
+);
+CREATE TABLE IF NOT EXISTS failsafe_summaries (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	file TEXT NOT NULL,
+	module TEXT NOT NULL,
+	result TEXT,
+	timeout INTEGER NOT NULL,
+	completed INTEGER,
+	errors INTEGER,
+	failures INTEGER,
+	skipped INTEGER,
+	failure_message TEXT
+);
+CREATE INDEX IF NOT EXISTS suites_run_id ON suites(run_id);
+CREATE INDEX IF NOT EXISTS properties_suite_id ON properties(suite_id);
+CREATE INDEX IF NOT EXISTS cases_suite_id ON cases(suite_id);
+`
+
+// suitesColumns are the columns added to the suites table after it was first
+// released. They are added to databases created before.
+var suitesColumns = []string{"group_id", "artifact_id", "module_path", "plugin", "archive"}
+
+// sqliteConverter appends reports to an SQLite database. All reports of one
+// conversion are written in one transaction as a new run.
+type sqliteConverter struct {
+	db     *sql.DB
+	source string
+	tx     *sql.Tx
+	run    int64
+}
+
+func newSqliteConverter(dest, source string) (*sqliteConverter, error) {
+	db, err := sql.Open("sqlite", dest)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create schema in %q: %w", dest, err)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate schema in %q: %w", dest, err)
+	}
+	return &sqliteConverter{db: db, source: source}, nil
+}
+
+// migrate adds the suitesColumns missing in the suites table of a database
+// created by an earlier version.
+func migrate(db *sql.DB) error {
+	rows, err := db.Query("SELECT name FROM pragma_table_info('suites')")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	existing := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		existing[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, column := range suitesColumns {
+		if existing[column] {
+			continue
+		}
+		if _, err := db.Exec("ALTER TABLE suites ADD COLUMN " + column + " TEXT"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sc *sqliteConverter) write(from string, suites []TestSuite) error {
+	// the run is only created once a report could be decoded so failed
+	// conversions do not leave empty runs behind
+	if sc.tx == nil {
+		if err := sc.begin(); err != nil {
+			return fileError(PhaseWrite, from, err)
+		}
+	}
+
+	for _, suite := range suites {
+		if err := sc.insert(from, suite); err != nil {
+			return fileError(PhaseWrite, from, err)
+		}
+	}
+	return nil
+}
+
+func (sc *sqliteConverter) writeSummary(s FailsafeSummary) error {
+	if sc.tx == nil {
+		if err := sc.begin(); err != nil {
+			return fileError(PhaseWrite, s.File, err)
+		}
+	}
+
+	_, err := sc.tx.Exec(`INSERT INTO failsafe_summaries (run_id, file, module, result, timeout, completed, errors, failures, skipped, failure_message)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		sc.run, s.File, s.Module(), text(s.Result), s.Timeout,
+		count(s.Completed), count(s.Errors), count(s.Failures), count(s.Skipped), text(s.FailureMessage))
+	return fileError(PhaseWrite, s.File, err)
+}
+
+func (sc *sqliteConverter) begin() error {
+	tx, err := sc.db.Begin()
+	if err != nil {
+		return err
+	}
+	res, err := tx.Exec("INSERT INTO runs (created_at, source) VALUES (?, ?)", time.Now().UTC().Format(time.RFC3339), sc.source)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	sc.run, err = res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	sc.tx = tx
+	return nil
+}
+
+func (sc *sqliteConverter) insert(file string, suite TestSuite) error {
+	var project Project
+	if suite.Project != nil {
+		project = *suite.Project
+	}
+	res, err := sc.tx.Exec(`INSERT INTO suites (run_id, file, module, basedir, group_id, artifact_id, module_path, plugin, archive, name, time, tests, errors, skipped, failures)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		sc.run, file, suite.Module(), suite.Basedir(),
+		text(project.GroupID), text(project.ArtifactID), text(project.Path), text(string(suite.Plugin)), text(suite.Archive),
+		suite.Name, seconds(suite.Time), count(suite.Tests), count(suite.Errors), count(suite.Skipped), count(suite.Failures))
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range suite.Properties.Properties {
+		_, err := sc.tx.Exec("INSERT INTO properties (suite_id, name, value) VALUES (?, ?, ?)", id, p.Name, p.Value)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, c := range suite.Cases {
+		var failure Result
+		if r := c.Result(); r != nil {
+			failure = *r
+		}
+		_, err := sc.tx.Exec(`INSERT INTO cases (suite_id, class, name, time, status, failure_type, failure_message, failure_stack_trace, reruns, rerun_outcome)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, c.ClassName, c.Name, seconds(c.Time), string(c.Status()),
+			text(failure.Type), text(failure.Message), text(strings.TrimSpace(failure.StackTrace)),
+			c.Reruns(), text(string(c.RerunOutcome())))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close commits the run and closes the database.
+func (sc *sqliteConverter) Close() error {
+	if sc.tx != nil {
+		if err := sc.tx.Commit(); err != nil {
+			sc.db.Close()
+			return err
+		}
+	}
+	return sc.db.Close()
+}
+
+// seconds stores a missing attribute as NULL.
+func seconds(s Seconds) any {
+	if s.Text == "" {
+		return nil
+	}
+	return s.Value
+}
+
+// count stores a missing attribute as NULL.
+func count(c Count) any {
+	if c.Text == "" {
+		return nil
+	}
+	return c.Value
+}
+
+// text stores an empty string as NULL.
+func text(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,187 @@
+package surefire
+
+import (
+	"bytes"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestConverterSQLite(t *testing.T) {
+	t.Run("AppendsEachConversionAsRun", func(t *testing.T) {
+		dest := filepath.Join(t.TempDir(), "results.db")
+
+		for i := 0; i < 2; i++ {
+			var w bytes.Buffer
+			c := Converter{From: "testdata/input", Log: &w, Format: FormatSQLite}
+
+			err := c.To(dest)
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+		}
+
+		db, err := sql.Open("sqlite", dest)
+		if err != nil {
+			t.Fatalf("failed to open database due to %s", err)
+		}
+		defer db.Close()
+
+		counts := map[string]int{}
+		for _, table := range []string{"runs", "suites", "properties", "cases"} {
+			var n int
+			if err := db.QueryRow("SELECT count(*) FROM " + table).Scan(&n); err != nil {
+				t.Fatalf("failed to count %s due to %s", table, err)
+			}
+			counts[table] = n
+		}
+		want := map[string]int{
+			"runs":       2,
+			"suites":     4,
+			"properties": 2 * (67 + 67),
+			"cases":      2 * 5,
+		}
+		if diff := cmp.Diff(want, counts); diff != "" {
+			t.Errorf("rows mismatch (-want +got): \n%s", diff)
+		}
+
+		var module string
+		var time float64
+		var status string
+		err = db.QueryRow(`SELECT s.module, c.time, c.status FROM cases c JOIN suites s ON s.id = c.suite_id
+			WHERE s.run_id = 2 AND c.name = 'testMappingAggregation'`).Scan(&module, &time, &status)
+		if err != nil {
+			t.Fatalf("failed to query case due to %s", err)
+		}
+		if diff := cmp.Diff([]any{"dhis-service-analytics", 46.089, "passed"}, []any{module, time, status}); diff != "" {
+			t.Errorf("case mismatch (-want +got): \n%s", diff)
+		}
+	})
+
+	t.Run("WritesProjectPluginAndArchiveOfSuites", func(t *testing.T) {
+		src := t.TempDir()
+		writeProject(t, filepath.Join(src, "core"), `<project>
+  <groupId>org.hisp.dhis</groupId>
+  <artifactId>dhis-service-core</artifactId>
+</project>`, "org.hisp.dhis.CoreTest")
+		archive := filepath.Join(src, "api-reports.zip")
+		writeZip(t, archive, artifact)
+		dest := filepath.Join(t.TempDir(), "results.db")
+		var w bytes.Buffer
+		c := Converter{From: src, Log: &w, Strict: true, Format: FormatSQLite}
+
+		err := c.To(dest)
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		db, err := sql.Open("sqlite", dest)
+		if err != nil {
+			t.Fatalf("failed to open database due to %s", err)
+		}
+		defer db.Close()
+		rows, err := db.Query("SELECT name, group_id, artifact_id, module_path, plugin, archive FROM suites ORDER BY name")
+		if err != nil {
+			t.Fatalf("failed to query suites due to %s", err)
+		}
+		defer rows.Close()
+		var got [][]any
+		for rows.Next() {
+			var name, groupID, artifactID, modulePath, plugin string
+			var archive sql.NullString
+			if err := rows.Scan(&name, &groupID, &artifactID, &modulePath, &plugin, &archive); err != nil {
+				t.Fatalf("failed to scan suite due to %s", err)
+			}
+			got = append(got, []any{name, groupID, artifactID, modulePath, plugin, archive})
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatalf("failed to query suites due to %s", err)
+		}
+		want := [][]any{
+			{"org.hisp.dhis.ApiTest", "org.hisp.dhis", "dhis-web-api", ".", "surefire", sql.NullString{String: archive, Valid: true}},
+			{"org.hisp.dhis.CoreTest", "org.hisp.dhis", "dhis-service-core", ".", "surefire", sql.NullString{}},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("suites mismatch (-want +got): \n%s", diff)
+		}
+	})
+
+	t.Run("AddsSuitesColumnsToExistingDatabase", func(t *testing.T) {
+		dest := filepath.Join(t.TempDir(), "results.db")
+		db, err := sql.Open("sqlite", dest)
+		if err != nil {
+			t.Fatalf("failed to open database due to %s", err)
+		}
+		defer db.Close()
+		// the suites table as created before it had any project columns
+		_, err = db.Exec(`CREATE TABLE suites (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	file TEXT NOT NULL,
+	module TEXT NOT NULL,
+	basedir TEXT NOT NULL,
+	name TEXT NOT NULL,
+	time REAL,
+	tests INTEGER,
+	errors INTEGER,
+	skipped INTEGER,
+	failures INTEGER
+)`)
+		if err != nil {
+			t.Fatalf("failed to create suites due to %s", err)
+		}
+		var w bytes.Buffer
+		c := Converter{From: "testdata/input", Log: &w, Strict: true, Format: FormatSQLite}
+
+		err = c.To(dest)
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		var n int
+		if err := db.QueryRow("SELECT count(*) FROM suites WHERE plugin = 'surefire' AND archive IS NULL").Scan(&n); err != nil {
+			t.Fatalf("failed to count suites due to %s", err)
+		}
+		if n != 2 {
+			t.Errorf("got %d suites, want 2", n)
+		}
+	})
+
+	t.Run("DoesNotCreateRunIfNothingIsConverted", func(t *testing.T) {
+		dest := filepath.Join(t.TempDir(), "results.db")
+
+		var w bytes.Buffer
+		c := Converter{From: t.TempDir(), Log: &w, Format: FormatSQLite}
+
+		err := c.To(dest)
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		db, err := sql.Open("sqlite", dest)
+		if err != nil {
+			t.Fatalf("failed to open database due to %s", err)
+		}
+		defer db.Close()
+		var n int
+		if err := db.QueryRow("SELECT count(*) FROM runs").Scan(&n); err != nil {
+			t.Fatalf("failed to count runs due to %s", err)
+		}
+		if n != 0 {
+			t.Errorf("got %d runs, want none", n)
+		}
+	})
+
+	t.Run("FailsIfDestIsADirectory", func(t *testing.T) {
+		var w bytes.Buffer
+		c := Converter{From: "testdata/input", Log: &w, Format: FormatSQLite}
+
+		err := c.To(t.TempDir())
+
+		if err == nil {
+			t.Fatal("expected an error but got none")
+		}
+	})
+}
@@ -0,0 +1,345 @@
+package surefire
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Summarizer aggregates Maven Surefire XML reports found in From per Maven
+// module and per class.
+type Summarizer struct {
+	From string
+	// Include, Exclude and FollowSymlinks select the reports like they do
+	// in Converter.
+	Include        []string
+	Exclude        []string
+	FollowSymlinks bool
+	Log            io.Writer
+	Debug          bool
+}
+
+// Summary holds the aggregates of all test cases. Aggregates are ordered by
+// time with the slowest first.
+type Summary struct {
+	Modules []Aggregate
+	Classes []Aggregate
+	// Slowest are the slowest test cases with the slowest first.
+	Slowest []TestResult
+	// NotPassed are the failed, errored and skipped test cases in the order
+	// of their reports.
+	NotPassed []TestResult
+}
+
+// slowestTests is the number of test cases in Summary.Slowest.
+const slowestTests = 10
+
+// TestResult is the outcome of a single test case.
+type TestResult struct {
+	Module string
+	Class  string
+	Test   string
+	Status Status
+	// Time is the duration of the test case in seconds.
+	Time float64
+	// Result describes why the test case did not pass. It is nil if it
+	// passed.
+	Result *Result
+}
+
+// Aggregate holds the totals of the test cases of a Maven module or a class.
+type Aggregate struct {
+	Module string
+	// Class is empty if the aggregate is of a module.
+	Class    string
+	Tests    int
+	Failures int
+	Errors   int
+	Skipped  int
+	// Time is the sum of test case durations in seconds.
+	Time float64
+	// Overhead is the time in seconds spent in test suites outside of their
+	// test cases like in setup and teardown.
+	Overhead float64
+	// P50, P90 and P99 are percentiles of the test case durations in seconds.
+	P50 float64
+	P90 float64
+	P99 float64
+
+	durations []float64
+}
+
+func (s Summarizer) Summarize() (Summary, error) {
+	filter := fileFilter{include: s.Include, exclude: s.Exclude, followSymlinks: s.FollowSymlinks}
+	if err := filter.validate(); err != nil {
+		return Summary{}, err
+	}
+	agg := newAggregator()
+	_, err := walk(s.From, s.Log, s.Debug, walkOptions{filter: filter}, func(path string, suites []TestSuite) error {
+		for _, suite := range suites {
+			agg.add(suite)
+		}
+		return nil
+	})
+	if err != nil {
+		return Summary{}, err
+	}
+	return agg.summary(), nil
+}
+
+// aggregator aggregates test suites per Maven module and per class.
+type aggregator struct {
+	modules map[[2]string]*Aggregate
+	classes map[[2]string]*Aggregate
+	tests   []TestResult
+}
+
+func newAggregator() *aggregator {
+	return &aggregator{modules: map[[2]string]*Aggregate{}, classes: map[[2]string]*Aggregate{}}
+}
+
+// add aggregates the test cases of the suite. Suites without test cases of
+// their own like the ones wrapping nested suites are skipped as their time is
+// already accounted for by the nested suites.
+func (ag *aggregator) add(suite TestSuite) {
+	if len(suite.Cases) == 0 {
+		return
+	}
+	aggregate := func(m map[[2]string]*Aggregate, module, class string) *Aggregate {
+		k := [2]string{module, class}
+		if _, ok := m[k]; !ok {
+			m[k] = &Aggregate{Module: module, Class: class}
+		}
+		return m[k]
+	}
+
+	var casesTime float64
+	for _, c := range suite.Cases {
+		casesTime += c.Time.Value
+	}
+
+	module := suite.Module()
+	// suites without a time have no overhead
+	var overhead float64
+	if suite.Time.Text != "" {
+		overhead = suite.Time.Value - casesTime
+	}
+	aggregate(ag.modules, module, "").Overhead += overhead
+	for _, class := range overheadClasses(suite) {
+		aggregate(ag.classes, module, class.name).Overhead += overhead * class.share
+	}
+	for _, c := range suite.Cases {
+		aggregate(ag.modules, module, "").add(c)
+		aggregate(ag.classes, module, c.ClassName).add(c)
+		ag.tests = append(ag.tests, TestResult{
+			Module: module,
+			Class:  c.ClassName,
+			Test:   c.Name,
+			Status: c.Status(),
+			Time:   c.Time.Value,
+			Result: c.Result(),
+		})
+	}
+}
+
+// classShare is the share of a suite's overhead attributed to a class.
+type classShare struct {
+	name  string
+	share float64
+}
+
+// overheadClasses returns the classes the overhead of the suite is attributed
+// to. The overhead belongs to the class the suite is named after if it owns
+// any of its test cases. It is otherwise split evenly among the classes owning
+// the test cases like the nested classes of a suite named after the outer
+// class so no class without tests is aggregated.
+func overheadClasses(suite TestSuite) []classShare {
+	var classes []string
+	seen := map[string]bool{}
+	for _, c := range suite.Cases {
+		if c.ClassName == suite.Name {
+			return []classShare{{name: suite.Name, share: 1}}
+		}
+		if !seen[c.ClassName] {
+			seen[c.ClassName] = true
+			classes = append(classes, c.ClassName)
+		}
+	}
+	shares := make([]classShare, len(classes))
+	for i, class := range classes {
+		shares[i] = classShare{name: class, share: 1 / float64(len(classes))}
+	}
+	return shares
+}
+
+// summary returns the aggregates ordered by time with the slowest first.
+func (ag *aggregator) summary() Summary {
+	var summary Summary
+	for _, a := range ag.modules {
+		summary.Modules = append(summary.Modules, a.finish())
+	}
+	for _, a := range ag.classes {
+		summary.Classes = append(summary.Classes, a.finish())
+	}
+	sortAggregates(summary.Modules)
+	sortAggregates(summary.Classes)
+
+	summary.Slowest = make([]TestResult, len(ag.tests))
+	copy(summary.Slowest, ag.tests)
+	sort.SliceStable(summary.Slowest, func(i, j int) bool {
+		return summary.Slowest[i].Time > summary.Slowest[j].Time
+	})
+	if len(summary.Slowest) > slowestTests {
+		summary.Slowest = summary.Slowest[:slowestTests]
+	}
+	for _, t := range ag.tests {
+		if t.Status != StatusPassed {
+			summary.NotPassed = append(summary.NotPassed, t)
+		}
+	}
+	return summary
+}
+
+// Total returns the totals of all modules.
+func (s Summary) Total() Aggregate {
+	var total Aggregate
+	for _, m := range s.Modules {
+		total.Tests += m.Tests
+		total.Failures += m.Failures
+		total.Errors += m.Errors
+		total.Skipped += m.Skipped
+		total.Time += m.Time
+		total.Overhead += m.Overhead
+	}
+	return total
+}
+
+func (a *Aggregate) add(c TestCase) {
+	a.Tests++
+	switch c.Status() {
+	case StatusFailed:
+		a.Failures++
+	case StatusErrored:
+		a.Errors++
+	case StatusSkipped:
+		a.Skipped++
+	}
+	a.Time += c.Time.Value
+	a.durations = append(a.durations, c.Time.Value)
+}
+
+func (a *Aggregate) finish() Aggregate {
+	sort.Float64s(a.durations)
+	a.P50 = percentile(a.durations, 50)
+	a.P90 = percentile(a.durations, 90)
+	a.P99 = percentile(a.durations, 99)
+	return *a
+}
+
+// percentile returns the nearest-rank percentile p of the sorted durations.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func sortAggregates(aggregates []Aggregate) {
+	sort.Slice(aggregates, func(i, j int) bool {
+		a, b := aggregates[i], aggregates[j]
+		if a.Time != b.Time {
+			return a.Time > b.Time
+		}
+		if a.Module != b.Module {
+			return a.Module < b.Module
+		}
+		return a.Class < b.Class
+	})
+}
+
+func summaryHeader() []string {
+	return []string{
+		"module",
+		"class",
+		"tests [number]",
+		"failures [number]",
+		"errors [number]",
+		"skipped [number]",
+		"duration [seconds]",
+		"suite overhead [seconds]",
+		"p50 [seconds]",
+		"p90 [seconds]",
+		"p99 [seconds]",
+	}
+}
+
+func (a Aggregate) record() []string {
+	return []string{
+		a.Module,
+		a.Class,
+		strconv.Itoa(a.Tests),
+		strconv.Itoa(a.Failures),
+		strconv.Itoa(a.Errors),
+		strconv.Itoa(a.Skipped),
+		formatSeconds(a.Time),
+		formatSeconds(a.Overhead),
+		formatSeconds(a.P50),
+		formatSeconds(a.P90),
+		formatSeconds(a.P99),
+	}
+}
+
+// formatSeconds formats seconds with the millisecond precision Surefire
+// reports durations in.
+func formatSeconds(f float64) string {
+	return strconv.FormatFloat(f, 'f', 3, 64)
+}
+
+// WriteCSV writes the module aggregates followed by the class aggregates.
+// The class column is empty for module aggregates.
+func (s Summary) WriteCSV(w io.Writer) error {
+	c := csv.NewWriter(w)
+	if err := c.Write(summaryHeader()); err != nil {
+		return err
+	}
+	for _, aggregates := range [][]Aggregate{s.Modules, s.Classes} {
+		for _, a := range aggregates {
+			if err := c.Write(a.record()); err != nil {
+				return err
+			}
+		}
+	}
+	c.Flush()
+	return c.Error()
+}
+
+// WriteTable writes the module and the class aggregates as aligned tables.
+func (s Summary) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	write := func(cells []string) {
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+
+	h := summaryHeader()
+	write(append([]string{h[0]}, h[2:]...))
+	for _, a := range s.Modules {
+		r := a.record()
+		write(append([]string{r[0]}, r[2:]...))
+	}
+	write(nil)
+	write(h)
+	for _, a := range s.Classes {
+		write(a.record())
+	}
+
+	return tw.Flush()
+}
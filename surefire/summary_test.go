@@ -0,0 +1,217 @@
+package surefire
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestSummarizer(t *testing.T) {
+	var w bytes.Buffer
+	s := Summarizer{From: "testdata/input", Log: &w}
+
+	got, err := s.Summarize()
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+
+	want := Summary{
+		Modules: []Aggregate{
+			{
+				Module:   "dhis-service-analytics",
+				Tests:    4,
+				Time:     171.205,
+				Overhead: 0.012,
+				P50:      41.879,
+				P90:      46.089,
+				P99:      46.089,
+			},
+			{
+				Module:   "dhis-service-administration",
+				Tests:    1,
+				Skipped:  1,
+				Overhead: 0.003,
+			},
+		},
+		Classes: []Aggregate{
+			{
+				Module:   "dhis-service-analytics",
+				Class:    "org.hisp.dhis.analytics.data.AnalyticsServiceTest",
+				Tests:    4,
+				Time:     171.205,
+				Overhead: 0.012,
+				P50:      41.879,
+				P90:      46.089,
+				P99:      46.089,
+			},
+			{
+				Module:   "dhis-service-administration",
+				Class:    "org.hisp.dhis.maintenance.HardDeleteAuditTest",
+				Tests:    1,
+				Skipped:  1,
+				Overhead: 0.003,
+			},
+		},
+		Slowest: []TestResult{
+			{Module: "dhis-service-analytics", Class: "org.hisp.dhis.analytics.data.AnalyticsServiceTest", Test: "testMappingAggregation", Status: StatusPassed, Time: 46.089},
+			{Module: "dhis-service-analytics", Class: "org.hisp.dhis.analytics.data.AnalyticsServiceTest", Test: "testGridAggregation", Status: StatusPassed, Time: 42.103},
+			{Module: "dhis-service-analytics", Class: "org.hisp.dhis.analytics.data.AnalyticsServiceTest", Test: "testSetAggregation", Status: StatusPassed, Time: 41.879},
+			{Module: "dhis-service-analytics", Class: "org.hisp.dhis.analytics.data.AnalyticsServiceTest", Test: "queryValidationResultTable", Status: StatusPassed, Time: 41.134},
+			{Module: "dhis-service-administration", Class: "org.hisp.dhis.maintenance.HardDeleteAuditTest", Status: StatusSkipped, Result: &Result{}},
+		},
+		NotPassed: []TestResult{
+			{Module: "dhis-service-administration", Class: "org.hisp.dhis.maintenance.HardDeleteAuditTest", Status: StatusSkipped, Result: &Result{}},
+		},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(Aggregate{}), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("Summarize() mismatch (-want +got): \n%s", diff)
+	}
+	if w.Len() != 0 {
+		t.Errorf("expected no log output but got %q", w.String())
+	}
+}
+
+func TestSummarizerNestedSuites(t *testing.T) {
+	src := t.TempDir()
+	writeReport(t, src, "TEST-Outer.xml", `<testsuite name="Outer" time="10" tests="2" errors="0" skipped="0" failures="0">
+  <testsuite name="com.A" time="4" tests="1" errors="0" skipped="0" failures="0">
+    <testcase name="a" classname="com.A" time="3"/>
+  </testsuite>
+  <testsuite name="com.B" time="6" tests="1" errors="0" skipped="0" failures="0">
+    <testcase name="b" classname="com.B" time="6"/>
+  </testsuite>
+</testsuite>`)
+	var w bytes.Buffer
+	s := Summarizer{From: src, Log: &w}
+
+	got, err := s.Summarize()
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+
+	want := []Aggregate{
+		{Tests: 2, Time: 9, Overhead: 1, P50: 3, P90: 6, P99: 6},
+	}
+	if diff := cmp.Diff(want, got.Modules, cmpopts.IgnoreUnexported(Aggregate{}), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("Summarize() modules mismatch (-want +got): \n%s", diff)
+	}
+	want = []Aggregate{
+		{Class: "com.B", Tests: 1, Time: 6, P50: 6, P90: 6, P99: 6},
+		{Class: "com.A", Tests: 1, Time: 3, Overhead: 1, P50: 3, P90: 3, P99: 3},
+	}
+	if diff := cmp.Diff(want, got.Classes, cmpopts.IgnoreUnexported(Aggregate{}), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("Summarize() classes mismatch (-want +got): \n%s", diff)
+	}
+}
+
+func TestSummarizerOverheadOfNestedClasses(t *testing.T) {
+	src := t.TempDir()
+	writeReport(t, src, "TEST-com.Outer.xml", `<testsuite name="com.Outer" time="9" tests="3" errors="0" skipped="0" failures="0">
+  <testcase name="a" classname="com.Outer$A" time="1"/>
+  <testcase name="b" classname="com.Outer$A" time="1"/>
+  <testcase name="c" classname="com.Outer$B" time="4"/>
+</testsuite>`)
+	var w bytes.Buffer
+	s := Summarizer{From: src, Log: &w}
+
+	got, err := s.Summarize()
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+
+	want := []Aggregate{
+		{Class: "com.Outer$B", Tests: 1, Time: 4, Overhead: 1.5, P50: 4, P90: 4, P99: 4},
+		{Class: "com.Outer$A", Tests: 2, Time: 2, Overhead: 1.5, P50: 1, P90: 1, P99: 1},
+	}
+	if diff := cmp.Diff(want, got.Classes, cmpopts.IgnoreUnexported(Aggregate{}), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("Summarize() classes mismatch (-want +got): \n%s", diff)
+	}
+}
+
+func TestSummarizerOverheadWithoutSuiteTime(t *testing.T) {
+	src := t.TempDir()
+	writeReport(t, src, "TEST-com.A.xml", `<testsuite name="com.A" tests="1" errors="0" skipped="0" failures="0">
+  <testcase name="a" classname="com.A" time="2"/>
+</testsuite>`)
+	var w bytes.Buffer
+	s := Summarizer{From: src, Log: &w}
+
+	got, err := s.Summarize()
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+
+	want := []Aggregate{
+		{Class: "com.A", Tests: 1, Time: 2, P50: 2, P90: 2, P99: 2},
+	}
+	if diff := cmp.Diff(want, got.Classes, cmpopts.IgnoreUnexported(Aggregate{}), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("Summarize() classes mismatch (-want +got): \n%s", diff)
+	}
+	if got.Modules[0].Overhead != 0 {
+		t.Errorf("got module overhead %f, want 0", got.Modules[0].Overhead)
+	}
+}
+
+func TestSummaryWriteCSV(t *testing.T) {
+	s := Summary{
+		Modules: []Aggregate{{Module: "dhis-service-analytics", Tests: 2, Failures: 1, Time: 3.5, Overhead: 0.25, P50: 1.5, P90: 2, P99: 2}},
+		Classes: []Aggregate{{Module: "dhis-service-analytics", Class: "org.hisp.dhis.analytics.data.AnalyticsServiceTest", Tests: 2, Failures: 1, Time: 3.5, Overhead: 0.25, P50: 1.5, P90: 2, P99: 2}},
+	}
+	var w bytes.Buffer
+
+	err := s.WriteCSV(&w)
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+
+	want := `module,class,tests [number],failures [number],errors [number],skipped [number],duration [seconds],suite overhead [seconds],p50 [seconds],p90 [seconds],p99 [seconds]
+dhis-service-analytics,,2,1,0,0,3.500,0.250,1.500,2.000,2.000
+dhis-service-analytics,org.hisp.dhis.analytics.data.AnalyticsServiceTest,2,1,0,0,3.500,0.250,1.500,2.000,2.000
+`
+	if diff := cmp.Diff(want, w.String()); diff != "" {
+		t.Errorf("WriteCSV() mismatch (-want +got): \n%s", diff)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	tc := map[string]struct {
+		durations []float64
+		p         float64
+		want      float64
+	}{
+		"Empty":     {durations: nil, p: 50, want: 0},
+		"One":       {durations: []float64{3}, p: 99, want: 3},
+		"Median":    {durations: []float64{1, 2, 3, 4}, p: 50, want: 2},
+		"P90":       {durations: []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, p: 90, want: 9},
+		"P99OfFew":  {durations: []float64{1, 2, 3}, p: 99, want: 3},
+		"P0IsFirst": {durations: []float64{1, 2, 3}, p: 0, want: 1},
+	}
+
+	for k, v := range tc {
+		t.Run(k, func(t *testing.T) {
+			if got := percentile(v.durations, v.p); got != v.want {
+				t.Errorf("percentile(%v, %v) = %v, want %v", v.durations, v.p, got, v.want)
+			}
+		})
+	}
+}
+
+func TestSummarizerFiltersReports(t *testing.T) {
+	var w bytes.Buffer
+	s := Summarizer{From: "testdata/input", Log: &w, Exclude: []string{"*HardDeleteAuditTest.xml"}}
+
+	got, err := s.Summarize()
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+
+	var modules []string
+	for _, m := range got.Modules {
+		modules = append(modules, m.Module)
+	}
+	if diff := cmp.Diff([]string{"dhis-service-analytics"}, modules); diff != "" {
+		t.Errorf("Summarize() modules mismatch (-want +got): \n%s", diff)
+	}
+}
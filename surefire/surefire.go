@@ -1,27 +1,421 @@
 package surefire
 
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path/filepath"
+)
+
 type TestSuite struct {
-	Name       string     `xml:"name,attr"`
-	Time       string     `xml:"time,attr"`
-	Tests      string     `xml:"tests,attr"`
-	Errors     string     `xml:"errors,attr"`
-	Skipped    string     `xml:"skipped,attr"`
-	Failures   string     `xml:"failures,attr"`
-	Properties Properties `xml:"properties"`
-	Cases      []TestCase `xml:"testcase"`
+	Name       string     `xml:"name,attr" json:"name"`
+	Time       Seconds    `xml:"time,attr" json:"time"`
+	Tests      Count      `xml:"tests,attr" json:"tests"`
+	Errors     Count      `xml:"errors,attr" json:"errors"`
+	Skipped    Count      `xml:"skipped,attr" json:"skipped"`
+	Failures   Count      `xml:"failures,attr" json:"failures"`
+	Properties Properties `xml:"properties" json:"properties"`
+	Cases      []TestCase `xml:"testcase" json:"cases"`
+	// Project is the Maven project the report was found in. It is nil if
+	// there is no pom.xml in any directory above the report.
+	Project *Project `xml:"-" json:"project,omitempty"`
+	// Plugin is the Maven plugin that wrote the report. It is empty if the
+	// report was not read from a directory.
+	Plugin Plugin `xml:"-" json:"plugin,omitempty"`
+	// Archive is the path of the archive the report was read from. It is
+	// empty if the report was read from disk.
+	Archive string `xml:"-" json:"archive,omitempty"`
+}
+
+// ErrNotReport is returned when decoding an XML document whose root is
+// neither a testsuite nor a testsuites element.
+var ErrNotReport = errors.New("not a Surefire report")
+
+// decode decodes all test suites in the report in r. The system-out and
+// system-err of test cases are only decoded if output is true.
+func decode(r io.Reader, output bool) ([]TestSuite, error) {
+	return decodeCases(r, output, func(suite *TestSuite, c TestCase) error {
+		suite.Cases = append(suite.Cases, c)
+		return nil
+	})
+}
+
+// decodeCases decodes the report in r token by token calling fn for every
+// test case as soon as its element is closed. The suite passed to fn holds
+// the attributes and properties of the suite the test case belongs to. The
+// returned suites only hold the test cases fn added to them. Elements like
+// system-out which can be megabytes in size are skipped without keeping them
+// in memory unless output is true.
+//
+// The root of a report is either a testsuite or a testsuites element
+// aggregating several of them. Suites can be nested. Every suite is returned
+// in document order with its own attributes and properties. ErrNotReport is
+// returned if the root is any other element.
+func decodeCases(r io.Reader, output bool, fn func(suite *TestSuite, c TestCase) error) ([]TestSuite, error) {
+	sd := suiteDecoder{d: xml.NewDecoder(r), output: output, fn: fn}
+	for {
+		t, err := sd.d.Token()
+		if err != nil {
+			return sd.suites, err
+		}
+		if start, ok := t.(xml.StartElement); ok {
+			switch start.Name.Local {
+			case "testsuites":
+				return sd.suites, sd.aggregate()
+			case "testsuite":
+				return sd.suites, sd.suite(start)
+			}
+			return nil, ErrNotReport
+		}
+	}
+}
+
+type suiteDecoder struct {
+	d      *xml.Decoder
+	output bool
+	fn     func(suite *TestSuite, c TestCase) error
+	suites []TestSuite
+}
+
+// aggregate decodes the suites of a testsuites element.
+func (sd *suiteDecoder) aggregate() error {
+	for {
+		t, err := sd.d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := t.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "testsuite" {
+				err = sd.suite(t)
+			} else {
+				err = sd.d.Skip()
+			}
+			if err != nil {
+				return err
+			}
+		case xml.EndElement:
+			// the decoder makes sure this closes the testsuites element
+			return nil
+		}
+	}
+}
+
+// suite decodes the testsuite element started by start.
+func (sd *suiteDecoder) suite(start xml.StartElement) error {
+	// reserve the position of the suite so it precedes its nested suites
+	i := len(sd.suites)
+	sd.suites = append(sd.suites, TestSuite{})
+
+	var suite TestSuite
+	line, _ := sd.d.InputPos()
+	if err := suite.decodeAttrs(start); err != nil {
+		sd.suites[i] = suite
+		return atLine(err, line)
+	}
+	for {
+		t, err := sd.d.Token()
+		if err != nil {
+			sd.suites[i] = suite
+			return err
+		}
+		switch t := t.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "properties":
+				var p Properties
+				if err := sd.d.DecodeElement(&p, &t); err != nil {
+					return err
+				}
+				suite.Properties.Properties = append(suite.Properties.Properties, p.Properties...)
+			case "testcase":
+				c, err := sd.testCase(t)
+				if err != nil {
+					return err
+				}
+				if err := sd.fn(&suite, c); err != nil {
+					return err
+				}
+			case "testsuite":
+				if err := sd.suite(t); err != nil {
+					return err
+				}
+			default:
+				if err := sd.d.Skip(); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			// the decoder makes sure this closes the testsuite element
+			sd.suites[i] = suite
+			return nil
+		}
+	}
+}
+
+// testCase decodes the testcase element started by start.
+func (sd *suiteDecoder) testCase(start xml.StartElement) (TestCase, error) {
+	line, _ := sd.d.InputPos()
+	if !sd.output {
+		var c TestCase
+		err := sd.d.DecodeElement(&c, &start)
+		return c, atLine(err, line)
+	}
+
+	var c testCaseOutput
+	if err := sd.d.DecodeElement(&c, &start); err != nil {
+		return TestCase{}, atLine(err, line)
+	}
+	c.TestCase.SystemOut = c.SystemOut
+	c.TestCase.SystemErr = c.SystemErr
+	return c.TestCase, nil
+}
+
+func (ts *TestSuite) decodeAttrs(start xml.StartElement) error {
+	for _, a := range start.Attr {
+		var err error
+		switch a.Name.Local {
+		case "name":
+			ts.Name = a.Value
+		case "time":
+			err = ts.Time.UnmarshalXMLAttr(a)
+		case "tests":
+			err = ts.Tests.UnmarshalXMLAttr(a)
+		case "errors":
+			err = ts.Errors.UnmarshalXMLAttr(a)
+		case "skipped":
+			err = ts.Skipped.UnmarshalXMLAttr(a)
+		case "failures":
+			err = ts.Failures.UnmarshalXMLAttr(a)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// atLine sets the line of an AttrError in err so invalid attributes can be
+// found in the report.
+func atLine(err error, line int) error {
+	var ae *AttrError
+	if errors.As(err, &ae) && ae.Line == 0 {
+		ae.Line = line
+	}
+	return err
+}
+
+// Basedir returns the basedir property Surefire sets to the directory of the
+// Maven module the tests ran in.
+func (ts TestSuite) Basedir() string {
+	basedir, _ := ts.Property("basedir")
+	return basedir
+}
+
+// Property returns the value of the property with the given name. The last
+// one wins if the property is set more than once.
+func (ts TestSuite) Property(name string) (string, bool) {
+	var value string
+	var found bool
+	for _, p := range ts.Properties.Properties {
+		if p.Name == name {
+			value, found = p.Value, true
+		}
+	}
+	return value, found
+}
+
+// Module returns the name of the Maven module the tests ran in. It is the
+// artifactId of the project the report was found in. It is derived from the
+// basedir property if the project is not known and is empty if neither is.
+func (ts TestSuite) Module() string {
+	if ts.Project != nil {
+		return ts.Project.ArtifactID
+	}
+	basedir := ts.Basedir()
+	if basedir == "" {
+		return ""
+	}
+	return filepath.Base(basedir)
 }
 
 type Properties struct {
 	Properties []Property `xml:"property"`
 }
 
+// MarshalJSON encodes the properties as an array as the properties element
+// only wraps them in XML.
+func (p Properties) MarshalJSON() ([]byte, error) {
+	if p.Properties == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(p.Properties)
+}
+
+func (p *Properties) UnmarshalJSON(b []byte) error {
+	return json.Unmarshal(b, &p.Properties)
+}
+
 type Property struct {
-	Name  string `xml:"name,attr"`
-	Value string `xml:"value,attr"`
+	Name  string `xml:"name,attr" json:"name"`
+	Value string `xml:"value,attr" json:"value"`
 }
 
 type TestCase struct {
-	Name      string `xml:"name,attr"`
-	ClassName string `xml:"classname,attr"`
-	Time      string `xml:"time,attr"`
+	Name      string  `xml:"name,attr" json:"name"`
+	ClassName string  `xml:"classname,attr" json:"className"`
+	Time      Seconds `xml:"time,attr" json:"time"`
+	Failure   *Result `xml:"failure" json:"failure,omitempty"`
+	Error     *Result `xml:"error" json:"error,omitempty"`
+	Skipped   *Result `xml:"skipped" json:"skipped,omitempty"`
+	// Reruns are only written by Surefire if rerunFailingTestsCount is set.
+	FlakyFailures []Rerun `xml:"flakyFailure" json:"flakyFailures,omitempty"`
+	FlakyErrors   []Rerun `xml:"flakyError" json:"flakyErrors,omitempty"`
+	RerunFailures []Rerun `xml:"rerunFailure" json:"rerunFailures,omitempty"`
+	RerunErrors   []Rerun `xml:"rerunError" json:"rerunErrors,omitempty"`
+	// SystemOut and SystemErr are only decoded if asked for as they can be
+	// megabytes in size.
+	SystemOut string `xml:"-" json:"-"`
+	SystemErr string `xml:"-" json:"-"`
+}
+
+// testCaseOutput decodes a test case together with its output.
+type testCaseOutput struct {
+	TestCase
+	SystemOut string `xml:"system-out"`
+	SystemErr string `xml:"system-err"`
+}
+
+// MarshalJSON adds the status and rerun outcome derived from the test case to
+// its JSON encoding.
+func (tc TestCase) MarshalJSON() ([]byte, error) {
+	// testCase does not inherit the MarshalJSON method so it does not recurse
+	type testCase TestCase
+	return json.Marshal(struct {
+		testCase
+		Status       Status       `json:"status"`
+		Reruns       int          `json:"reruns"`
+		RerunOutcome RerunOutcome `json:"rerunOutcome,omitempty"`
+	}{
+		testCase:     testCase(tc),
+		Status:       tc.Status(),
+		Reruns:       tc.Reruns(),
+		RerunOutcome: tc.RerunOutcome(),
+	})
+}
+
+// Result describes why a test case did not pass. Surefire writes it as a
+// failure, error or skipped element with the stack trace as its content.
+type Result struct {
+	Message    string `xml:"message,attr" json:"message,omitempty"`
+	Type       string `xml:"type,attr" json:"type,omitempty"`
+	StackTrace string `xml:",chardata" json:"stackTrace,omitempty"`
+}
+
+// Rerun describes a failed run of a test case that was rerun by Surefire.
+// Unlike Result the stack trace is written in its own element.
+type Rerun struct {
+	Message    string `xml:"message,attr" json:"message,omitempty"`
+	Type       string `xml:"type,attr" json:"type,omitempty"`
+	StackTrace string `xml:"stackTrace" json:"stackTrace,omitempty"`
+}
+
+func (r Rerun) result() *Result {
+	return &Result{Message: r.Message, Type: r.Type, StackTrace: r.StackTrace}
+}
+
+// Status is the outcome of a test case.
+type Status string
+
+const (
+	StatusPassed  Status = "passed"
+	StatusFailed  Status = "failed"
+	StatusErrored Status = "errored"
+	StatusSkipped Status = "skipped"
+)
+
+// Status returns the outcome of the test case. A test case is only reported
+// once by Surefire so at most one of failure, error or skipped is expected.
+func (tc TestCase) Status() Status {
+	switch {
+	case tc.Error != nil:
+		return StatusErrored
+	case tc.Failure != nil:
+		return StatusFailed
+	case tc.Skipped != nil:
+		return StatusSkipped
+	}
+	return StatusPassed
+}
+
+// Result returns the failure, error or skipped result of the test case or nil
+// if it passed.
+func (tc TestCase) Result() *Result {
+	switch tc.Status() {
+	case StatusErrored:
+		return tc.Error
+	case StatusFailed:
+		return tc.Failure
+	case StatusSkipped:
+		return tc.Skipped
+	}
+	return nil
+}
+
+// RerunOutcome is the outcome of a test case that was rerun.
+type RerunOutcome string
+
+const (
+	// RerunNone means the test case was run once.
+	RerunNone RerunOutcome = ""
+	// RerunFlaky means the test case passed after failing at least once.
+	RerunFlaky RerunOutcome = "flaky"
+	// RerunFailed means the test case failed on every rerun.
+	RerunFailed RerunOutcome = "failed-after-rerun"
+)
+
+// Reruns returns the number of times the test case was rerun.
+func (tc TestCase) Reruns() int {
+	return len(tc.FlakyFailures) + len(tc.FlakyErrors) + len(tc.RerunFailures) + len(tc.RerunErrors)
+}
+
+// RerunOutcome returns whether the test case was flaky or kept failing when
+// it was rerun.
+func (tc TestCase) RerunOutcome() RerunOutcome {
+	switch {
+	case len(tc.FlakyFailures)+len(tc.FlakyErrors) > 0:
+		return RerunFlaky
+	case len(tc.RerunFailures)+len(tc.RerunErrors) > 0:
+		return RerunFailed
+	}
+	return RerunNone
+}
+
+// Attempt is a single run of a test case.
+type Attempt struct {
+	Status Status
+	// Result is nil if the attempt passed.
+	Result *Result
+}
+
+// Attempts returns every run of the test case in the order Surefire ran
+// them. A flaky test case fails before it passes, while a test case that
+// failed after rerun fails on its first run and on every rerun.
+func (tc TestCase) Attempts() []Attempt {
+	var attempts []Attempt
+	for _, r := range tc.FlakyFailures {
+		attempts = append(attempts, Attempt{Status: StatusFailed, Result: r.result()})
+	}
+	for _, r := range tc.FlakyErrors {
+		attempts = append(attempts, Attempt{Status: StatusErrored, Result: r.result()})
+	}
+	attempts = append(attempts, Attempt{Status: tc.Status(), Result: tc.Result()})
+	for _, r := range tc.RerunFailures {
+		attempts = append(attempts, Attempt{Status: StatusFailed, Result: r.result()})
+	}
+	for _, r := range tc.RerunErrors {
+		attempts = append(attempts, Attempt{Status: StatusErrored, Result: r.result()})
+	}
+	return attempts
 }
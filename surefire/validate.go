@@ -0,0 +1,345 @@
+package surefire
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// Schema is the version of the surefire-test-report XSD a report declares.
+type Schema string
+
+const (
+	// SchemaUnknown is the schema of reports that do not declare one.
+	SchemaUnknown Schema = ""
+	// Schema30 is surefire-test-report-3.0.xsd written by Surefire 3.
+	Schema30 Schema = "3.0"
+	// SchemaLegacy is surefire-test-report.xsd written by Surefire 2.
+	SchemaLegacy Schema = "legacy"
+)
+
+const xsiNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+
+// schemas maps the file name of the schema in xsi:noNamespaceSchemaLocation
+// to its version.
+var schemas = map[string]Schema{
+	"surefire-test-report-3.0.xsd": Schema30,
+	"surefire-test-report.xsd":     SchemaLegacy,
+}
+
+// element is what the schema allows in an element.
+type element struct {
+	attrs    []string
+	required []string
+	children []string
+}
+
+// elements are the elements of the schema by name. The testsuites root and
+// nested testsuite elements are not part of the schema but written when
+// reports are merged so they are accepted like the decoder does.
+var elements = map[string]element{
+	"testsuites": {
+		attrs:    []string{"name", "time", "tests", "errors", "skipped", "failures"},
+		children: []string{"testsuite"},
+	},
+	"testsuite": {
+		attrs:    []string{"name", "time", "tests", "errors", "skipped", "failures", "group", "version"},
+		required: []string{"name", "tests", "errors", "skipped", "failures"},
+		children: []string{"properties", "testcase", "testsuite"},
+	},
+	"properties": {
+		children: []string{"property"},
+	},
+	"property": {
+		attrs:    []string{"name", "value"},
+		required: []string{"name", "value"},
+	},
+	"testcase": {
+		attrs:    []string{"name", "classname", "group", "time"},
+		required: []string{"name"},
+		children: []string{"failure", "error", "skipped", "rerunFailure", "rerunError", "flakyFailure", "flakyError", "system-out", "system-err"},
+	},
+	"failure":      resultElement,
+	"error":        resultElement,
+	"rerunFailure": rerunElement,
+	"rerunError":   rerunElement,
+	"flakyFailure": rerunElement,
+	"flakyError":   rerunElement,
+	"skipped": {
+		attrs: []string{"message"},
+	},
+	"stackTrace": {},
+	"system-out": {},
+	"system-err": {},
+}
+
+var (
+	resultElement = element{attrs: []string{"message", "type"}}
+	rerunElement  = element{attrs: []string{"message", "type", "time"}, children: []string{"stackTrace", "system-out", "system-err"}}
+)
+
+// Problem is a violation of the schema found in a report.
+type Problem struct {
+	// Line is the line of the element or 0 if it is not known.
+	Line    int
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return p.Message
+	}
+	return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+}
+
+// Validation is the result of validating one report.
+type Validation struct {
+	Path string
+	// Schema is the schema the report declares.
+	Schema Schema
+	// Report is false if the file is not a Surefire report at all.
+	Report   bool
+	Problems []Problem
+}
+
+// Valid returns true if the file is a Surefire report without problems.
+func (v Validation) Valid() bool {
+	return v.Report && len(v.Problems) == 0
+}
+
+// Validator validates the Maven Surefire XML reports found in From against
+// the surefire-test-report schema. Reports written by Surefire 3 are validated
+// against version 3.0 and older ones against the schema of Surefire 2.
+type Validator struct {
+	From string
+	// Include, Exclude and FollowSymlinks select the reports like they do
+	// in Converter.
+	Include        []string
+	Exclude        []string
+	FollowSymlinks bool
+	Log            io.Writer
+}
+
+// Validations are the results of validating reports in the order of their
+// paths.
+type Validations []Validation
+
+// Validate validates every report. Files that cannot be read are returned as
+// invalid.
+func (v Validator) Validate() (Validations, error) {
+	filter := fileFilter{include: v.Include, exclude: v.Exclude, followSymlinks: v.FollowSymlinks}
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	found, _, err := reports(v.From, v.Log, filter, false)
+	defer found.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	validations := make(Validations, 0, len(found.files))
+	for _, rf := range found.files {
+		validation, err := validateFile(rf)
+		rf.done()
+		if err != nil {
+			validation = Validation{Path: rf.path, Problems: []Problem{{Message: err.Error()}}}
+		}
+		validations = append(validations, validation)
+	}
+	return validations, nil
+}
+
+// Invalid returns the validations of files that are not valid reports.
+func (vs Validations) Invalid() Validations {
+	var invalid Validations
+	for _, v := range vs {
+		if !v.Valid() {
+			invalid = append(invalid, v)
+		}
+	}
+	return invalid
+}
+
+// WriteTable writes one line per file with its problems indented below it.
+func (vs Validations) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, v := range vs {
+		status := "ok"
+		switch {
+		case !v.Report:
+			status = "not a report"
+		case !v.Valid():
+			status = "invalid"
+		}
+		schema := string(v.Schema)
+		if v.Schema == SchemaUnknown {
+			schema = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", status, schema, v.Path)
+		for _, p := range v.Problems {
+			fmt.Fprintf(tw, "\t\t  %s\n", p)
+		}
+	}
+	return tw.Flush()
+}
+
+func validateFile(rf reportFile) (Validation, error) {
+	r, err := rf.fsys.Open(rf.name)
+	if err != nil {
+		return Validation{}, err
+	}
+	defer r.Close()
+
+	v, err := validate(r)
+	v.Path = rf.path
+	return v, err
+}
+
+// validate validates the report in r. It only returns an error if r cannot
+// be read. Malformed XML is returned as a problem.
+func validate(r io.Reader) (Validation, error) {
+	var v Validation
+	d := xml.NewDecoder(r)
+	// parents holds the names of the open elements
+	var parents []string
+	for {
+		line, _ := d.InputPos()
+		t, err := d.Token()
+		if err == io.EOF {
+			if !v.Report && len(v.Problems) == 0 {
+				v.Problems = append(v.Problems, Problem{Message: "no root element"})
+			}
+			return v, nil
+		}
+		if err != nil {
+			return v, syntaxProblem(&v, err)
+		}
+
+		switch t := t.(type) {
+		case xml.StartElement:
+			name := t.Name.Local
+			if len(parents) == 0 {
+				if name != "testsuite" && name != "testsuites" {
+					v.Problems = append(v.Problems, Problem{Line: line, Message: fmt.Sprintf("root element %q is not testsuite", name)})
+					return v, nil
+				}
+				v.Report = true
+				v.Schema = schemaOf(t)
+				v.Problems = append(v.Problems, checkSchema(t, v.Schema, line)...)
+			} else if parent := parents[len(parents)-1]; !slices.Contains(elements[parent].children, name) {
+				v.Problems = append(v.Problems, Problem{Line: line, Message: fmt.Sprintf("unexpected element %q in %s", name, parent)})
+				if err := d.Skip(); err != nil {
+					return v, syntaxProblem(&v, err)
+				}
+				continue
+			}
+			v.Problems = append(v.Problems, checkAttrs(t, line)...)
+			parents = append(parents, name)
+		case xml.EndElement:
+			parents = parents[:len(parents)-1]
+		}
+	}
+}
+
+// syntaxProblem adds a syntax error as problem to v. Any other error is
+// returned.
+func syntaxProblem(v *Validation, err error) error {
+	var se *xml.SyntaxError
+	if errors.As(err, &se) {
+		v.Problems = append(v.Problems, Problem{Line: se.Line, Message: se.Msg})
+		return nil
+	}
+	return err
+}
+
+// isXSI returns true if name is in the XML schema instance namespace. The
+// prefix is kept as namespace if it is not declared.
+func isXSI(name xml.Name) bool {
+	return name.Space == xsiNamespace || name.Space == "xsi"
+}
+
+// schemaOf returns the schema declared by the root element start.
+func schemaOf(start xml.StartElement) Schema {
+	for _, a := range start.Attr {
+		if isXSI(a.Name) && a.Name.Local == "noNamespaceSchemaLocation" {
+			location := a.Value[strings.LastIndex(a.Value, "/")+1:]
+			if schema, ok := schemas[location]; ok {
+				return schema
+			}
+		}
+	}
+	for _, a := range start.Attr {
+		if a.Name.Space == "" && a.Name.Local == "version" && a.Value == string(Schema30) {
+			return Schema30
+		}
+	}
+	return SchemaUnknown
+}
+
+// checkSchema checks the schema location and version of the root element
+// start declaring schema.
+func checkSchema(start xml.StartElement, schema Schema, line int) []Problem {
+	var problems []Problem
+	for _, a := range start.Attr {
+		switch {
+		case isXSI(a.Name) && a.Name.Local == "noNamespaceSchemaLocation":
+			location := a.Value[strings.LastIndex(a.Value, "/")+1:]
+			if _, ok := schemas[location]; !ok {
+				problems = append(problems, Problem{Line: line, Message: fmt.Sprintf("unknown schema %q", a.Value)})
+			}
+		case a.Name.Space == "" && a.Name.Local == "version":
+			if schema == SchemaLegacy {
+				problems = append(problems, Problem{Line: line, Message: "attribute \"version\" is not allowed by the legacy schema"})
+			} else if a.Value != string(Schema30) {
+				problems = append(problems, Problem{Line: line, Message: fmt.Sprintf("unknown version %q", a.Value)})
+			}
+		}
+	}
+	return problems
+}
+
+// checkAttrs checks the attributes of the element started by start.
+func checkAttrs(start xml.StartElement, line int) []Problem {
+	var problems []Problem
+	e := elements[start.Name.Local]
+	seen := map[string]bool{}
+	for _, a := range start.Attr {
+		// namespace declarations and schema instance attributes are allowed
+		// on any element
+		if a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns") || isXSI(a.Name) {
+			continue
+		}
+		name := a.Name.Local
+		seen[name] = true
+		if a.Name.Space != "" || !slices.Contains(e.attrs, name) {
+			problems = append(problems, Problem{Line: line, Message: fmt.Sprintf("unexpected attribute %q in %s", name, start.Name.Local)})
+			continue
+		}
+		if err := checkNumber(name, a.Value); err != nil {
+			problems = append(problems, Problem{Line: line, Message: fmt.Sprintf("invalid %s %q in %s: %s", name, a.Value, start.Name.Local, err)})
+		}
+	}
+	for _, name := range e.required {
+		if !seen[name] {
+			problems = append(problems, Problem{Line: line, Message: fmt.Sprintf("%s is missing attribute %q", start.Name.Local, name)})
+		}
+	}
+	return problems
+}
+
+// checkNumber returns an error if the attribute is a number that cannot be
+// decoded.
+func checkNumber(name, value string) error {
+	var err error
+	switch name {
+	case "time":
+		_, err = parseSeconds(value)
+	case "tests", "errors", "skipped", "failures":
+		_, err = parseCount(value)
+	}
+	return err
+}
@@ -0,0 +1,172 @@
+package surefire
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidate(t *testing.T) {
+	tc := map[string]struct {
+		in   string
+		want Validation
+	}{
+		"Schema30": {
+			in: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:noNamespaceSchemaLocation="https://maven.apache.org/surefire/maven-surefire-plugin/xsd/surefire-test-report-3.0.xsd" version="3.0" name="a" time="1" tests="1" errors="0" skipped="0" failures="1">
+  <properties>
+    <property name="java.version" value="17"/>
+  </properties>
+  <testcase name="b" classname="a" time="1">
+    <failure message="boom" type="java.lang.AssertionError">stack</failure>
+    <system-out>out</system-out>
+  </testcase>
+</testsuite>`,
+			want: Validation{Schema: Schema30, Report: true},
+		},
+		"SchemaLegacy": {
+			in: `<testsuite xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:noNamespaceSchemaLocation="https://maven.apache.org/surefire/maven-surefire-plugin/xsd/surefire-test-report.xsd" name="a" time="1" tests="1" errors="0" skipped="0" failures="0">
+  <testcase name="b" classname="a" time="1">
+    <flakyFailure message="boom" type="java.lang.AssertionError">
+      <stackTrace>stack</stackTrace>
+    </flakyFailure>
+  </testcase>
+</testsuite>`,
+			want: Validation{Schema: SchemaLegacy, Report: true},
+		},
+		"WithoutSchema": {
+			in:   `<testsuite name="a" tests="0" errors="0" skipped="0" failures="0"/>`,
+			want: Validation{Report: true},
+		},
+		"TestSuitesRoot": {
+			in: `<testsuites>
+  <testsuite name="a" tests="0" errors="0" skipped="0" failures="0"/>
+</testsuites>`,
+			want: Validation{Report: true},
+		},
+		"MissingRequiredAttributes": {
+			in: `<testsuite name="a" tests="1" errors="0">
+  <testcase classname="a"/>
+</testsuite>`,
+			want: Validation{Report: true, Problems: []Problem{
+				{Line: 1, Message: `testsuite is missing attribute "skipped"`},
+				{Line: 1, Message: `testsuite is missing attribute "failures"`},
+				{Line: 2, Message: `testcase is missing attribute "name"`},
+			}},
+		},
+		"UnexpectedElementsAndAttributes": {
+			in: `<testsuite name="a" tests="1" errors="0" skipped="0" failures="0" hostname="ci">
+  <testcase name="b" time="fast">
+    <output>nested <b>element</b></output>
+  </testcase>
+</testsuite>`,
+			want: Validation{Report: true, Problems: []Problem{
+				{Line: 1, Message: `unexpected attribute "hostname" in testsuite`},
+				{Line: 2, Message: `invalid time "fast" in testcase: not a number`},
+				{Line: 3, Message: `unexpected element "output" in testcase`},
+			}},
+		},
+		"UnknownSchemaAndVersion": {
+			in:   `<testsuite xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:noNamespaceSchemaLocation="junit.xsd" version="4.0" name="a" tests="0" errors="0" skipped="0" failures="0"/>`,
+			want: Validation{Report: true, Problems: []Problem{{Line: 1, Message: `unknown schema "junit.xsd"`}, {Line: 1, Message: `unknown version "4.0"`}}},
+		},
+		"VersionInLegacySchema": {
+			in:   `<testsuite xsi:noNamespaceSchemaLocation="surefire-test-report.xsd" version="3.0" name="a" tests="0" errors="0" skipped="0" failures="0"/>`,
+			want: Validation{Schema: SchemaLegacy, Report: true, Problems: []Problem{{Line: 1, Message: `attribute "version" is not allowed by the legacy schema`}}},
+		},
+		"NotAReport": {
+			in:   `<project><artifactId>a</artifactId></project>`,
+			want: Validation{Problems: []Problem{{Line: 1, Message: `root element "project" is not testsuite`}}},
+		},
+		"MalformedXML": {
+			in: `<testsuite name="a" tests="0" errors="0" skipped="0" failures="0">
+</testcase>`,
+			want: Validation{Report: true, Problems: []Problem{{Line: 2, Message: "element <testsuite> closed by </testcase>"}}},
+		},
+		"Empty": {
+			in:   ``,
+			want: Validation{Problems: []Problem{{Message: "no root element"}}},
+		},
+	}
+
+	for k, v := range tc {
+		t.Run(k, func(t *testing.T) {
+			got, err := validate(strings.NewReader(v.in))
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+
+			if diff := cmp.Diff(v.want, got); diff != "" {
+				t.Errorf("validate() mismatch (-want +got): \n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidator(t *testing.T) {
+	t.Run("ReportsEveryFile", func(t *testing.T) {
+		src := t.TempDir()
+		writeReport(t, src, "TEST-a.xml", `<testsuite name="a" tests="0" errors="0" skipped="0" failures="0"/>`)
+		writeReport(t, src, "TEST-b.xml", `<testsuite name="b" tests="0"/>`)
+		writeReport(t, src, "logback-test.xml", `<configuration/>`)
+
+		got, err := Validator{From: src, Log: io.Discard}.Validate()
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		if len(got) != 3 {
+			t.Fatalf("expected 3 validations but got %d", len(got))
+		}
+		if n := len(got.Invalid()); n != 2 {
+			t.Errorf("expected 2 invalid files but got %d", n)
+		}
+		var w bytes.Buffer
+		if err := got.WriteTable(&w); err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+		want := "ok            -  " + filepath.Join(src, "TEST-a.xml") + "\n" +
+			"invalid       -  " + filepath.Join(src, "TEST-b.xml") + "\n" +
+			`                   line 1: testsuite is missing attribute "errors"` + "\n" +
+			`                   line 1: testsuite is missing attribute "skipped"` + "\n" +
+			`                   line 1: testsuite is missing attribute "failures"` + "\n" +
+			"not a report  -  " + filepath.Join(src, "logback-test.xml") + "\n" +
+			`                   line 1: root element "configuration" is not testsuite` + "\n"
+		if diff := cmp.Diff(want, w.String()); diff != "" {
+			t.Errorf("WriteTable() mismatch (-want +got): \n%s", diff)
+		}
+	})
+
+	t.Run("AcceptsTestdata", func(t *testing.T) {
+		got, err := Validator{From: "testdata/input", Log: io.Discard}.Validate()
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+
+		if invalid := got.Invalid(); len(invalid) > 0 {
+			t.Errorf("expected all reports to be valid but got %v", invalid)
+		}
+	})
+}
+
+func TestConverterSkipsOtherXML(t *testing.T) {
+	src := t.TempDir()
+	writeReport(t, src, "TEST-a.xml", `<testsuite name="a" tests="1" errors="0" skipped="0" failures="0">
+  <testcase name="b" classname="a" time="1"/>
+</testsuite>`)
+	writeReport(t, src, "logback-test.xml", `<configuration/>`)
+
+	var w bytes.Buffer
+	err := Converter{From: src, Log: &w, Strict: true}.To(t.TempDir())
+
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+	if w.Len() > 0 {
+		t.Errorf("expected no logs but got %q", w.String())
+	}
+}
@@ -0,0 +1,545 @@
+package surefire
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// walk decodes every XML report in from using the given number of workers
+// and calls fn with the test suites of every report ordered by their path.
+// opts.cases is called with the test cases of every report instead if it is
+// set. fn and opts.cases are only called by one goroutine at a time unless
+// opts.concurrent is set. Files or directories that cannot be read and
+// reports that cannot be decoded or fn fails on are logged, skipped and
+// returned as FileErrors. The walk is only stopped if from itself cannot be
+// read. XML files that are not reports are skipped without being logged
+// unless debug is set. Failsafe summaries are passed to opts.summary after
+// all reports or skipped if it is nil.
+func walk(from string, log io.Writer, debug bool, opts walkOptions, fn func(path string, suites []TestSuite) error) (FileErrors, error) {
+	found, failed, err := reports(from, log, opts.filter, opts.summary != nil)
+	defer found.Close()
+	if err != nil {
+		return failed, err
+	}
+	if opts.found != nil {
+		opts.found(found.files)
+	}
+
+	var summaries []decoded
+	cases := opts.cases
+	if opts.concurrent {
+		// the workers pass the test cases on themselves
+		cases = nil
+	}
+	for p := range decodeAll(found.files, newProjectResolver(from), opts) {
+		d, err := p.receive(cases, opts.discard)
+		if d.pomErr != nil && debug {
+			// the module falls back to the basedir of the report
+			fmt.Fprintf(log, "Ignored pom.xml of %q due to %s\n", d.path, d.pomErr)
+		}
+		if d.failsafe {
+			// summaries are decoded along the reports so archives can be
+			// closed once read but are passed on after all reports
+			summaries = append(summaries, d)
+			continue
+		}
+		if errors.Is(d.err, ErrNotReport) {
+			// other XML files like test resources are expected next to
+			// reports
+			if debug {
+				fmt.Fprintf(log, "Skipped %q as it is not a Surefire report\n", d.path)
+			}
+			continue
+		}
+		if err == nil {
+			err = d.err
+		}
+		if err == nil && opts.cases == nil {
+			err = fn(d.path, d.suites)
+		}
+		if err != nil {
+			fe := asFileError(d.path, err)
+			fmt.Fprintf(log, "Failed to convert %q due to %s\n", d.path, fe.Err)
+			failed = append(failed, fe)
+			continue
+		}
+		if debug {
+			fmt.Fprintf(log, "Converted %q\n", d.path)
+		}
+	}
+
+	for _, d := range summaries {
+		err := d.err
+		if err == nil {
+			err = opts.summary(d.summary)
+		}
+		if err != nil {
+			fe := asFileError(d.path, err)
+			fmt.Fprintf(log, "Failed to convert %q due to %s\n", d.path, fe.Err)
+			failed = append(failed, fe)
+			continue
+		}
+		if debug {
+			fmt.Fprintf(log, "Converted %q\n", d.path)
+		}
+	}
+
+	return failed, nil
+}
+
+// reportFile is a report or Failsafe summary found by reports. It is read
+// from name in fsys. path is the file on disk or the file in an archive
+// joined to the path of the archive.
+type reportFile struct {
+	path string
+	fsys fs.FS
+	name string
+	// archive is the path of the archive the report is in. It is empty if the
+	// report is on disk.
+	archive string
+	// failsafe is true if the file is a Failsafe summary.
+	failsafe bool
+}
+
+// done marks the file as read so the archive it is in is closed once all
+// its files are read. It must be called once the file is read.
+func (rf reportFile) done() {
+	if a, ok := rf.fsys.(*archiveFS); ok {
+		a.done()
+	}
+}
+
+// diskReport returns the report in file on disk.
+func diskReport(file string) (reportFile, error) {
+	fsys, name, err := diskFS(file)
+	if err != nil {
+		return reportFile{}, err
+	}
+	return reportFile{path: file, fsys: fsys, name: name}, nil
+}
+
+// found holds the reports and Failsafe summaries found in lexical order.
+// Archives are opened whenever their files are read. Close must be called
+// once the files are read to close the archives that are still open.
+type found struct {
+	files    []reportFile
+	archives []io.Closer
+}
+
+func (f found) Close() error {
+	var errs []error
+	for _, a := range f.archives {
+		errs = append(errs, a.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// reports returns all reports in from selected by filter in lexical order.
+// Failsafe summaries are returned along the reports if summaries is true.
+// They are found regardless of the include patterns unless they are
+// excluded. Archives are searched like directories. Files at the root of an
+// archive are also matched as if they were in a directory named like the
+// archive without its extension. Archives without any reports are logged.
+func reports(from string, log io.Writer, filter fileFilter, summaries bool) (found, FileErrors, error) {
+	var f found
+	var failed FileErrors
+	// matched counts the reports selected by filter
+	var matched int
+	// visited holds the real paths of directories walked through symbolic
+	// links so cycles are only walked once
+	visited := map[string]bool{}
+	absFrom, err := filepath.Abs(from)
+	if err != nil {
+		return f, nil, err
+	}
+	// add adds the file rel to f if it is selected by filter under any of
+	// its absolute paths using forward slashes. It returns true if the file
+	// is added as report.
+	add := func(rf reportFile, rel string, abs ...string) bool {
+		for _, a := range abs {
+			if filter.excluded(rel, a) {
+				return false
+			}
+		}
+		if isFailsafeSummary(rel) {
+			if summaries {
+				rf.failsafe = true
+				f.files = append(f.files, rf)
+			}
+			return false
+		}
+		for _, a := range abs {
+			if filter.included(rel, a) {
+				f.files = append(f.files, rf)
+				matched++
+				return true
+			}
+		}
+		return false
+	}
+	var walkDir func(root string) error
+	walkDir = func(root string) error {
+		// using WalkDir as godoc of Walk declares it as being more efficient
+		return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if from == path {
+					// stop the Walk if from cannot be read
+					return fmt.Errorf("failed to walk %q: %w", from, err)
+				}
+				fmt.Fprintf(log, "Failed to process %q due to %s\n", path, err)
+				failed = append(failed, &FileError{Path: path, Phase: PhaseWalk, Err: err})
+				return nil
+			}
+			if d.IsDir() {
+				if path != root && skipDirs[d.Name()] {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.Type()&fs.ModeSymlink != 0 && filter.followSymlinks {
+				if target, err := os.Stat(path); err == nil && target.IsDir() {
+					return followDir(path, visited, walkDir)
+				}
+			}
+
+			rel, err := filepath.Rel(from, path)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			abs := filepath.ToSlash(filepath.Join(absFrom, rel))
+			if rel == "." {
+				// from is a file so patterns are matched against its name
+				rel = filepath.Base(from)
+			}
+			if isArchive(path) {
+				if filter.excluded(rel, abs) {
+					return nil
+				}
+				a := &archiveFS{path: path}
+				a.mu.Lock()
+				err := a.open()
+				a.mu.Unlock()
+				if err != nil {
+					fmt.Fprintf(log, "Failed to process %q due to %s\n", path, err)
+					failed = append(failed, &FileError{Path: path, Phase: PhaseOpen, Err: err})
+					return nil
+				}
+				f.archives = append(f.archives, a)
+				files, reports := len(f.files), 0
+				failed = append(failed, walkArchive(path, a, log, func(rf reportFile) {
+					paths := []string{abs + "/" + rf.name}
+					if !strings.Contains(rf.name, "/") {
+						paths = append(paths, archiveDir(abs)+"/"+rf.name)
+					}
+					if add(rf, rf.name, paths...) {
+						reports++
+					}
+				})...)
+				// the archive is opened again once its files are decoded
+				a.pending = len(f.files) - files
+				if err := a.Close(); err != nil {
+					return err
+				}
+				if reports == 0 {
+					fmt.Fprintf(log, "Found no reports in %q matching the include patterns\n", path)
+				}
+				return nil
+			}
+
+			rf, err := diskReport(path)
+			if err != nil {
+				return err
+			}
+			add(rf, rel, abs)
+			return nil
+		})
+	}
+
+	if real, err := filepath.EvalSymlinks(from); err == nil {
+		visited[real] = true
+	}
+	err = walkDir(from)
+	// archives log on their own if none of their files matched
+	if err == nil && matched == 0 && !isArchive(from) {
+		fmt.Fprintf(log, "Found no reports in %q matching the include patterns\n", from)
+	}
+	return f, failed, err
+}
+
+// walkArchive calls fn with every file in the archive fsys found at path in
+// lexical order.
+func walkArchive(path string, fsys fs.FS, log io.Writer, fn func(rf reportFile)) FileErrors {
+	var failed FileErrors
+	fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		file := filepath.Join(path, filepath.FromSlash(name))
+		if err != nil {
+			fmt.Fprintf(log, "Failed to process %q due to %s\n", file, err)
+			failed = append(failed, &FileError{Path: file, Phase: PhaseWalk, Err: err})
+			return nil
+		}
+		if d.IsDir() {
+			if name != "." && skipDirs[d.Name()] {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			fn(reportFile{path: file, fsys: fsys, name: name, archive: path})
+		}
+		return nil
+	})
+	return failed
+}
+
+// followDir walks the directory the symbolic link at path points to unless it
+// or a directory above it has been walked before. This stops cycles and
+// reports from being walked twice.
+func followDir(path string, visited map[string]bool, walkDir func(string) error) error {
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+	for dir := real; ; dir = filepath.Dir(dir) {
+		if visited[dir] {
+			return nil
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	visited[real] = true
+	// WalkDir does not follow a root that is a symbolic link unless it ends
+	// in a separator
+	return walkDir(path + string(filepath.Separator))
+}
+
+// walkOptions control how walk decodes reports.
+type walkOptions struct {
+	// workers is the number of reports decoded in parallel. It defaults to
+	// GOMAXPROCS if below 1.
+	workers int
+	// output decodes the system-out and system-err of test cases.
+	output bool
+	// filter selects the reports to walk.
+	filter fileFilter
+	// summary is called with every Failsafe summary. Summaries are skipped
+	// if it is nil.
+	summary func(s FailsafeSummary) error
+	// cases is called instead of fn with the test cases of every report. The
+	// suite of a test case holds no test cases. It is not called for reports
+	// that fail to decode unless concurrent is set.
+	cases func(path string, cases iter.Seq2[TestSuite, TestCase]) error
+	// concurrent calls cases from the workers with the test cases of a
+	// report as they are decoded so the test cases of a report are never
+	// held in memory all at once. cases must be safe for concurrent use and
+	// is called in any order then. Test cases decoded before a report turns
+	// out to be broken are passed on as well and discarded afterwards.
+	// Otherwise cases is called in the order of the reports with the test
+	// cases of a report once it is decoded so every worker holds all test
+	// cases of the report it decodes in memory. Memory then grows with the
+	// size of the largest reports.
+	concurrent bool
+	// discard is called if a report whose test cases have been passed to
+	// cases fails so its partial output can be removed. It can be nil.
+	discard func(path string) error
+	// found is called with all reports and Failsafe summaries found before
+	// they are decoded. It can be nil.
+	found func(reports []reportFile)
+}
+
+type decoded struct {
+	// path is the path of the report in reportFile.
+	path   string
+	suites []TestSuite
+	// cases are the test cases of the report if they are passed to
+	// walkOptions.cases in the order of the reports.
+	cases []suiteCase
+	err   error
+	// pomErr is the error reading the pom.xml of the report. The report is
+	// decoded without a project.
+	pomErr error
+	// failsafe is true if summary is decoded instead of suites.
+	failsafe bool
+	summary  FailsafeSummary
+}
+
+// suiteCase is a test case together with its suite.
+type suiteCase struct {
+	suite TestSuite
+	c     TestCase
+}
+
+// pending is a report or Failsafe summary decoded by a worker.
+type pending struct {
+	result <-chan decoded
+}
+
+// receive waits for the report to be decoded. Its test cases are passed to fn
+// if it is not nil unless the report failed to decode. discard is called if
+// it is not nil and fn fails. The error of fn or discard is returned
+// separately from the decoded report.
+func (p pending) receive(fn func(path string, cases iter.Seq2[TestSuite, TestCase]) error, discard func(path string) error) (decoded, error) {
+	d := <-p.result
+	if fn == nil || d.failsafe || d.err != nil {
+		return d, nil
+	}
+
+	cases := d.cases
+	d.cases = nil
+	err := fn(d.path, func(yield func(TestSuite, TestCase) bool) {
+		for _, sc := range cases {
+			if !yield(sc.suite, sc.c) {
+				return
+			}
+		}
+	})
+	return d, discarded(d.path, err, d.err, discard)
+}
+
+// discarded calls discard if it is not nil and either writing or decoding the
+// report at path failed. It returns the error of writing or else of discard.
+func discarded(path string, err, decodeErr error, discard func(path string) error) error {
+	if (err != nil || decodeErr != nil) && discard != nil {
+		if derr := discard(path); err == nil {
+			err = derr
+		}
+	}
+	return err
+}
+
+// errStopped stops decoding a report whose test cases are no longer
+// consumed.
+var errStopped = errors.New("stopped decoding")
+
+// decodeAll decodes the reports and Failsafe summaries using a pool of
+// workers and resolves the Maven project and plugin they were written by. It
+// returns the pending reports in the order of reports, each receiving the
+// decoded report once it is ready. Only a bounded number of reports is
+// decoded ahead of the one that is received next so memory stays bounded if
+// reports are consumed slower than they are decoded. The test cases of
+// reports are decoded one at a time if opts.cases is set and passed to it by
+// the workers if opts.concurrent is set.
+func decodeAll(reports []reportFile, projects *projectResolver, opts walkOptions) <-chan pending {
+	workers := opts.workers
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	type job struct {
+		report reportFile
+		result chan decoded
+	}
+	decode := func(j job) decoded {
+		rf := j.report
+		defer rf.done()
+		if rf.failsafe {
+			s, err := decodeFailsafeSummary(rf)
+			if err != nil {
+				return decoded{path: rf.path, err: err, failsafe: true}
+			}
+			var pomErr error
+			s.Project, pomErr = projects.resolveFS(rf.fsys, rf.name)
+			return decoded{path: rf.path, pomErr: pomErr, failsafe: true, summary: s}
+		}
+
+		project, pomErr := projects.resolveFS(rf.fsys, rf.name)
+		plugin := pluginOf(rf.path)
+		if opts.cases != nil {
+			// derr is the error decoding the report which is only known once
+			// all its test cases are consumed
+			var derr error
+			cases := func(yield func(TestSuite, TestCase) bool) {
+				_, derr = decodeFileCases(rf, opts.output, func(suite *TestSuite, c TestCase) error {
+					s := *suite
+					s.Project = project
+					s.Plugin = plugin
+					s.Archive = rf.archive
+					if !yield(s, c) {
+						return errStopped
+					}
+					return nil
+				})
+			}
+			if !opts.concurrent {
+				var buffered []suiteCase
+				for s, c := range cases {
+					buffered = append(buffered, suiteCase{suite: s, c: c})
+				}
+				if derr != nil {
+					// no test case of a broken report is written
+					return decoded{path: rf.path, err: derr}
+				}
+				return decoded{path: rf.path, cases: buffered, pomErr: pomErr}
+			}
+
+			// the test cases are pulled so no output is written for files
+			// that fail to decode before their first test case like files
+			// that are not reports
+			next, stop := iter.Pull2(cases)
+			defer stop()
+			s, c, ok := next()
+			if !ok && derr != nil {
+				return decoded{path: rf.path, err: derr}
+			}
+			err := opts.cases(rf.path, func(yield func(TestSuite, TestCase) bool) {
+				for ; ok; s, c, ok = next() {
+					if !yield(s, c) {
+						return
+					}
+				}
+			})
+			// stops decoding test cases opts.cases did not consume as it
+			// failed
+			stop()
+			if errors.Is(derr, errStopped) {
+				derr = nil
+			}
+			err = discarded(rf.path, err, derr, opts.discard)
+			if err == nil {
+				err = derr
+			}
+			return decoded{path: rf.path, err: err, pomErr: pomErr}
+		}
+
+		suites, err := decodeFile(rf, opts.output)
+		if err != nil {
+			return decoded{path: rf.path, err: err}
+		}
+		for i := range suites {
+			suites[i].Project = project
+			suites[i].Plugin = plugin
+			suites[i].Archive = rf.archive
+		}
+		return decoded{path: rf.path, suites: suites, pomErr: pomErr}
+	}
+
+	jobs := make(chan job)
+	for range workers {
+		go func() {
+			for j := range jobs {
+				j.result <- decode(j)
+			}
+		}()
+	}
+
+	results := make(chan pending, workers)
+	go func() {
+		defer close(jobs)
+		defer close(results)
+		for _, rf := range reports {
+			j := job{report: rf, result: make(chan decoded, 1)}
+			results <- pending{result: j.result}
+			jobs <- j
+		}
+	}()
+
+	return results
+}
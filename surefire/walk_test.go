@@ -0,0 +1,275 @@
+package surefire
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWalk(t *testing.T) {
+	t.Run("ReportsFilesWhichCannotBeRead", func(t *testing.T) {
+		src := t.TempDir()
+		f := filepath.Join(src, "TEST-non-readable.xml")
+		err := os.WriteFile(f, []byte("data"), 0004)
+		if err != nil {
+			t.Fatalf("failed to create non-readable file for test: %s", err)
+		}
+
+		var w bytes.Buffer
+		failed, err := walk(src, &w, false, walkOptions{workers: 2}, func(path string, suites []TestSuite) error {
+			t.Errorf("expected fn not to be called for %q", path)
+			return nil
+		})
+
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+		if len(failed) != 1 || failed[0].Path != f || failed[0].Phase != PhaseOpen {
+			t.Errorf("expected open error for %q instead got %v", f, failed)
+		}
+	})
+
+	t.Run("CallsFnInPathOrder", func(t *testing.T) {
+		var got []string
+		_, err := walk("testdata/input", io.Discard, false, walkOptions{workers: 4}, func(path string, suites []TestSuite) error {
+			got = append(got, path)
+			return nil
+		})
+
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+		want := make([]string, len(got))
+		copy(want, got)
+		sort.Strings(want)
+		if len(got) == 0 {
+			t.Fatal("expected reports to be walked")
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("walk() order mismatch (-want +got): \n%s", diff)
+		}
+	})
+
+	t.Run("SelectsReportsUsingFilter", func(t *testing.T) {
+		src := t.TempDir()
+		for _, dir := range []string{
+			filepath.Join("core", "target", "surefire-reports"),
+			filepath.Join("core", "target", "failsafe-reports"),
+			filepath.Join("core", "src", "test", "resources"),
+			filepath.Join("web", "node_modules", "dep", "target", "surefire-reports"),
+			filepath.Join(".git", "target", "surefire-reports"),
+			filepath.Join("legacy", "target", "surefire-reports"),
+		} {
+			if err := os.MkdirAll(filepath.Join(src, dir), 0750); err != nil {
+				t.Fatalf("failed to create %q due to %s", dir, err)
+			}
+			writeReport(t, filepath.Join(src, dir), "TEST-a.xml", `<testsuite name="a"/>`)
+		}
+
+		got := walked(t, src, fileFilter{
+			include: []string{"**/surefire-reports/TEST-*.xml", "**/failsafe-reports/TEST-*.xml"},
+			exclude: []string{"legacy/**"},
+		})
+
+		want := []string{
+			filepath.Join(src, "core", "target", "failsafe-reports", "TEST-a.xml"),
+			filepath.Join(src, "core", "target", "surefire-reports", "TEST-a.xml"),
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("walk() mismatch (-want +got): \n%s", diff)
+		}
+	})
+
+	t.Run("IncludesReportsInSrcIfItIsTheReportsDirectory", func(t *testing.T) {
+		src := filepath.Join(t.TempDir(), "surefire-reports")
+		if err := os.Mkdir(src, 0750); err != nil {
+			t.Fatalf("failed to create %q due to %s", src, err)
+		}
+		writeReport(t, src, "TEST-a.xml", `<testsuite name="a"/>`)
+
+		got := walked(t, src, fileFilter{include: []string{"**/surefire-reports/TEST-*.xml"}})
+
+		if diff := cmp.Diff([]string{filepath.Join(src, "TEST-a.xml")}, got); diff != "" {
+			t.Errorf("walk() mismatch (-want +got): \n%s", diff)
+		}
+	})
+
+	t.Run("MatchesSrcByItsNameIfItIsAFile", func(t *testing.T) {
+		src := t.TempDir()
+		writeReport(t, src, "TEST-a.xml", `<testsuite name="a"/>`)
+		file := filepath.Join(src, "TEST-a.xml")
+
+		got := walked(t, file, fileFilter{include: []string{"*.xml"}})
+
+		if diff := cmp.Diff([]string{file}, got); diff != "" {
+			t.Errorf("walk() mismatch (-want +got): \n%s", diff)
+		}
+		if got := walked(t, file, fileFilter{exclude: []string{"TEST-*.xml"}}); len(got) != 0 {
+			t.Errorf("expected no reports but got %v", got)
+		}
+	})
+
+	t.Run("FollowsSymlinksOnlyIfEnabled", func(t *testing.T) {
+		src, other := t.TempDir(), t.TempDir()
+		writeReport(t, other, "TEST-a.xml", `<testsuite name="a"/>`)
+		if err := os.Symlink(other, filepath.Join(src, "linked")); err != nil {
+			t.Fatalf("failed to create symlink due to %s", err)
+		}
+		// a link to its own parent must not be walked forever
+		if err := os.Symlink(src, filepath.Join(other, "cycle")); err != nil {
+			t.Fatalf("failed to create symlink due to %s", err)
+		}
+
+		if got := walked(t, src, fileFilter{}); len(got) != 0 {
+			t.Errorf("expected no reports but got %v", got)
+		}
+
+		got := walked(t, src, fileFilter{followSymlinks: true})
+
+		if diff := cmp.Diff([]string{filepath.Join(src, "linked", "TEST-a.xml")}, got); diff != "" {
+			t.Errorf("walk() mismatch (-want +got): \n%s", diff)
+		}
+	})
+}
+
+// walked returns the paths of the reports walk calls fn with.
+func walked(t *testing.T, src string, filter fileFilter) []string {
+	t.Helper()
+	var got []string
+	_, err := walk(src, io.Discard, false, walkOptions{filter: filter}, func(path string, suites []TestSuite) error {
+		got = append(got, path)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+	return got
+}
+
+func TestDecodeAll(t *testing.T) {
+	const workers = 4
+	var cases strings.Builder
+	// more test cases than a worker could decode ahead so a report cannot
+	// be decoded to its end before its test cases are consumed if they are
+	// handed over one at a time
+	for i := range 1000 {
+		fmt.Fprintf(&cases, "  <testcase name=\"test%d\" classname=\"org.hisp.dhis.ATest\" time=\"0.1\"/>\n", i)
+	}
+	report := "<testsuite name=\"org.hisp.dhis.ATest\" time=\"100\" tests=\"1000\">\n" + cases.String() + "</testsuite>"
+
+	tc := map[string]walkOptions{
+		"Suites": {},
+		"CasesInOrderOfReports": {
+			cases: func(path string, cases iter.Seq2[TestSuite, TestCase]) error {
+				for range cases {
+				}
+				return nil
+			},
+		},
+		"CasesConcurrently": {
+			concurrent: true,
+			cases: func(path string, cases iter.Seq2[TestSuite, TestCase]) error {
+				for range cases {
+				}
+				return nil
+			},
+		},
+	}
+
+	for k, opts := range tc {
+		t.Run("Decodes"+k+"InParallel", func(t *testing.T) {
+			fsys := newBarrierFS(report, workers)
+			var reports []reportFile
+			for i := range workers {
+				name := fmt.Sprintf("TEST-%d.xml", i)
+				reports = append(reports, reportFile{path: name, fsys: fsys, name: name})
+			}
+			opts.workers = workers
+			fn := opts.cases
+			if opts.concurrent {
+				fn = nil
+			}
+
+			for p := range decodeAll(reports, &projectResolver{dirs: map[fsDir]*pomDir{}}, opts) {
+				d, err := p.receive(fn, nil)
+				if err == nil {
+					err = d.err
+				}
+				if err != nil {
+					t.Errorf("expected no error for %q but got %s", d.path, err)
+				}
+			}
+		})
+	}
+}
+
+// barrierFS serves the same report under any name starting with TEST-.
+// Reading the end of a report blocks until the end of n reports is read so
+// reports fail to decode if they are decoded one at a time.
+type barrierFS struct {
+	report  string
+	arrived sync.WaitGroup
+	all     chan struct{}
+}
+
+func newBarrierFS(report string, n int) *barrierFS {
+	b := &barrierFS{report: report, all: make(chan struct{})}
+	b.arrived.Add(n)
+	go func() {
+		b.arrived.Wait()
+		close(b.all)
+	}()
+	return b
+}
+
+func (b *barrierFS) Open(name string) (fs.File, error) {
+	if !strings.HasPrefix(name, "TEST-") {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &barrierFile{fsys: b, r: strings.NewReader(b.report)}, nil
+}
+
+type barrierFile struct {
+	fsys *barrierFS
+	r    *strings.Reader
+	once sync.Once
+	err  error
+}
+
+func (f *barrierFile) Read(p []byte) (int, error) {
+	if f.r.Len() > len(p) {
+		return f.r.Read(p)
+	}
+	f.once.Do(func() {
+		f.fsys.arrived.Done()
+		select {
+		case <-f.fsys.all:
+		case <-time.After(5 * time.Second):
+			f.err = errors.New("reports are decoded one at a time")
+		}
+	})
+	if f.err != nil {
+		return 0, f.err
+	}
+	return f.r.Read(p)
+}
+
+func (f *barrierFile) Stat() (fs.FileInfo, error) {
+	return nil, errors.New("not supported")
+}
+
+func (f *barrierFile) Close() error {
+	return nil
+}
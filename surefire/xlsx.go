@@ -0,0 +1,357 @@
+package surefire
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Cell styles defined in xlsxStyles by their index.
+const (
+	xlsxStyleText = iota
+	xlsxStyleHeader
+	xlsxStyleSeconds
+	xlsxStyleNumber
+)
+
+// xlsxCellLimit is the number of characters a cell can hold.
+const xlsxCellLimit = 32767
+
+// xlsxSheetNameLimit is the number of characters a sheet name can have.
+const xlsxSheetNameLimit = 31
+
+// xlsxWidthLimit is the widest a column is made in characters.
+const xlsxWidthLimit = 60
+
+// xlsxEncoder writes one workbook with a summary sheet of all modules followed
+// by a sheet per module with its tests. The tests sheets use the same columns
+// as CSV. The workbook is only written on Close as it needs all test suites.
+type xlsxEncoder struct {
+	w       io.Writer
+	opts    recordOptions
+	columns []column
+	agg     *aggregator
+	// records are the tests per module.
+	records map[string][][]string
+}
+
+func newXLSXEncoder(w io.Writer, opts recordOptions) (*xlsxEncoder, error) {
+	columns, err := opts.selected()
+	if err != nil {
+		return nil, err
+	}
+	return &xlsxEncoder{w: w, opts: opts, columns: columns, agg: newAggregator(), records: map[string][][]string{}}, nil
+}
+
+func (xe *xlsxEncoder) encode(suite TestSuite) error {
+	xe.agg.add(suite)
+	module := suite.Module()
+	for _, c := range suite.Cases {
+		for _, r := range rows(suite, c, xe.opts.attempts) {
+			xe.records[module] = append(xe.records[module], record(xe.columns, r))
+		}
+	}
+	return nil
+}
+
+func (xe *xlsxEncoder) Close() error {
+	h := summaryHeader()
+	summary := xlsxSheet{name: "Summary", header: append([]string{h[0]}, h[2:]...)}
+	for _, m := range xe.agg.summary().Modules {
+		r := m.record()
+		summary.records = append(summary.records, append([]string{r[0]}, r[2:]...))
+	}
+	sheets := []xlsxSheet{summary}
+
+	modules := make([]string, 0, len(xe.records))
+	for m := range xe.records {
+		modules = append(modules, m)
+	}
+	sort.Strings(modules)
+	for _, m := range modules {
+		sheets = append(sheets, xlsxSheet{name: m, header: header(xe.columns), records: xe.records[m]})
+	}
+	return writeWorkbook(xe.w, sheets)
+}
+
+// xlsxSheet is a worksheet with a header row followed by records.
+type xlsxSheet struct {
+	name    string
+	header  []string
+	records [][]string
+}
+
+// writeWorkbook writes the sheets as an Office Open XML workbook into w.
+// Columns with a unit like [seconds] or [number] in their header are written
+// as numbers. Header rows are frozen and have an autofilter.
+func writeWorkbook(w io.Writer, sheets []xlsxSheet) error {
+	names := sheetNames(sheets)
+	zw := zip.NewWriter(w)
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{name: "[Content_Types].xml", content: xlsxContentTypes(len(sheets))},
+		{name: "_rels/.rels", content: xlsxRels},
+		{name: "xl/workbook.xml", content: xlsxWorkbook(names, sheets)},
+		{name: "xl/_rels/workbook.xml.rels", content: xlsxWorkbookRels(len(sheets))},
+		{name: "xl/styles.xml", content: xlsxStyles},
+	}
+	for _, p := range parts {
+		f, err := zw.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, p.content); err != nil {
+			return err
+		}
+	}
+	for i, s := range sheets {
+		f, err := zw.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1))
+		if err != nil {
+			return err
+		}
+		if err := writeWorksheet(f, s); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeWorksheet(w io.Writer, s xlsxSheet) error {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`)
+	fmt.Fprintf(&b, `<dimension ref="%s"/>`, xlsxRange(s))
+	b.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+
+	b.WriteString(`<cols>`)
+	for i, width := range xlsxWidths(s) {
+		fmt.Fprintf(&b, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, width)
+	}
+	b.WriteString(`</cols>`)
+
+	b.WriteString(`<sheetData>`)
+	b.WriteString(`<row r="1">`)
+	for i, h := range s.header {
+		xlsxString(&b, xlsxCell(i, 1), xlsxStyleHeader, h)
+	}
+	b.WriteString(`</row>`)
+	for i, r := range s.records {
+		row := i + 2
+		fmt.Fprintf(&b, `<row r="%d">`, row)
+		for j, v := range r {
+			if v == "" {
+				continue
+			}
+			ref := xlsxCell(j, row)
+			if n, style, ok := xlsxNumber(s.header[j], v); ok {
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, n)
+				continue
+			}
+			xlsxString(&b, ref, xlsxStyleText, v)
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData>`)
+	fmt.Fprintf(&b, `<autoFilter ref="%s"/>`, xlsxRange(s))
+	b.WriteString(`</worksheet>`)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// xlsxString writes an inline string cell.
+func xlsxString(b *strings.Builder, ref string, style int, v string) {
+	if utf8.RuneCountInString(v) > xlsxCellLimit {
+		v = string([]rune(v)[:xlsxCellLimit])
+	}
+	fmt.Fprintf(b, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">`, ref, style)
+	xml.EscapeText(b, []byte(v))
+	b.WriteString(`</t></is></c>`)
+}
+
+// xlsxNumber returns the number and its style if the column with the given
+// header holds numbers. Columns hold numbers if their header has a unit.
+// Values are parsed like the attributes of reports so durations written in
+// any locale become numbers. Unlike attributes they can be negative like the
+// suite overhead of a suite taking less time than its test cases. Values
+// which are not numbers are kept as text.
+func xlsxNumber(header, v string) (string, int, bool) {
+	sign, abs := "", v
+	if strings.HasPrefix(v, "-") {
+		sign, abs = "-", v[1:]
+		if abs == "" {
+			return "", 0, false
+		}
+	}
+	switch {
+	case strings.HasSuffix(header, "[seconds]"):
+		f, err := parseSeconds(abs)
+		if err != nil {
+			return "", 0, false
+		}
+		return sign + strconv.FormatFloat(f, 'f', -1, 64), xlsxStyleSeconds, true
+	case strings.HasSuffix(header, "[number]"), strings.HasSuffix(header, "[bytes]"), strings.HasSuffix(header, "[lines]"):
+		n, err := parseCount(abs)
+		if err != nil {
+			return "", 0, false
+		}
+		return sign + strconv.Itoa(n), xlsxStyleNumber, true
+	}
+	return "", 0, false
+}
+
+// xlsxWidths returns the width of every column fitting its widest cell.
+func xlsxWidths(s xlsxSheet) []int {
+	widths := make([]int, len(s.header))
+	for i, h := range s.header {
+		// leaves room for the autofilter button
+		widths[i] = utf8.RuneCountInString(h) + 4
+	}
+	for _, r := range s.records {
+		for i, v := range r {
+			widths[i] = max(widths[i], utf8.RuneCountInString(v)+2)
+		}
+	}
+	for i := range widths {
+		widths[i] = min(widths[i], xlsxWidthLimit)
+	}
+	return widths
+}
+
+// xlsxRange returns the range of the header and the records of the sheet.
+func xlsxRange(s xlsxSheet) string {
+	last := max(len(s.header), 1) - 1
+	return "A1:" + xlsxCell(last, len(s.records)+1)
+}
+
+// xlsxCell returns the reference like B3 of the zero-based column and the
+// one-based row.
+func xlsxCell(col, row int) string {
+	var name []byte
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = append([]byte{byte('A' + (col-1)%26)}, name...)
+	}
+	return string(name) + strconv.Itoa(row)
+}
+
+// sheetNames returns unique names of the sheets that Excel accepts. Sheet
+// names cannot be longer than 31 characters, contain any of []:*?/\ or
+// start or end with an apostrophe. Names are compared ignoring case.
+func sheetNames(sheets []xlsxSheet) []string {
+	replacer := strings.NewReplacer("[", "_", "]", "_", ":", "_", "*", "_", "?", "_", "/", "_", `\`, "_")
+	seen := make(map[string]bool, len(sheets))
+	names := make([]string, len(sheets))
+	for i, s := range sheets {
+		base := strings.Trim(replacer.Replace(s.name), "'")
+		if base == "" {
+			base = "no module"
+		}
+		name := xlsxSheetName(base, "")
+		for n := 2; seen[strings.ToLower(name)]; n++ {
+			name = xlsxSheetName(base, fmt.Sprintf(" (%d)", n))
+		}
+		seen[strings.ToLower(name)] = true
+		names[i] = name
+	}
+	return names
+}
+
+// xlsxSheetName shortens base so it fits into a sheet name with the suffix.
+func xlsxSheetName(base, suffix string) string {
+	r := []rune(base)
+	if n := xlsxSheetNameLimit - utf8.RuneCountInString(suffix); len(r) > n {
+		r = r[:n]
+	}
+	return strings.TrimRight(string(r), "'") + suffix
+}
+
+func xlsxContentTypes(sheets int) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+	b.WriteString(`</Types>`)
+	return b.String()
+}
+
+const xlsxRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+func xlsxWorkbook(names []string, sheets []xlsxSheet) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`)
+	b.WriteString(`<sheets>`)
+	for i, name := range names {
+		b.WriteString(`<sheet name="`)
+		xml.EscapeText(&b, []byte(name))
+		fmt.Fprintf(&b, `" sheetId="%d" r:id="rId%d"/>`, i+1, i+1)
+	}
+	b.WriteString(`</sheets>`)
+	// Excel expects the range of an autofilter to also be defined by name
+	b.WriteString(`<definedNames>`)
+	for i, name := range names {
+		fmt.Fprintf(&b, `<definedName name="_xlnm._FilterDatabase" localSheetId="%d" hidden="1">`, i)
+		ref := absoluteRange(xlsxRange(sheets[i]))
+		xml.EscapeText(&b, []byte("'"+strings.ReplaceAll(name, "'", "''")+"'!"+ref))
+		b.WriteString(`</definedName>`)
+	}
+	b.WriteString(`</definedNames>`)
+	b.WriteString(`</workbook>`)
+	return b.String()
+}
+
+// absoluteRange turns a range like A1:C3 into $A$1:$C$3.
+func absoluteRange(r string) string {
+	refs := strings.Split(r, ":")
+	for i, ref := range refs {
+		j := strings.IndexAny(ref, "0123456789")
+		refs[i] = "$" + ref[:j] + "$" + ref[j:]
+	}
+	return strings.Join(refs, ":")
+}
+
+func xlsxWorkbookRels(sheets int) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, sheets+1)
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+// xlsxStyles defines the cell styles in the order of their constants like
+// xlsxStyleHeader. Seconds are shown with millisecond precision like in
+// Surefire reports.
+const xlsxStyles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="1"><numFmt numFmtId="164" formatCode="0.000"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="4">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="1" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
@@ -0,0 +1,230 @@
+package surefire
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestXLSXCell(t *testing.T) {
+	tc := map[string]struct {
+		col, row int
+		want     string
+	}{
+		"First":       {col: 0, row: 1, want: "A1"},
+		"LastLetter":  {col: 25, row: 2, want: "Z2"},
+		"TwoLetters":  {col: 26, row: 3, want: "AA3"},
+		"AZ":          {col: 51, row: 1, want: "AZ1"},
+		"BA":          {col: 52, row: 1, want: "BA1"},
+		"LastColumn":  {col: 16383, row: 1, want: "XFD1"},
+		"ThreeLetter": {col: 702, row: 10, want: "AAA10"},
+	}
+
+	for k, v := range tc {
+		t.Run(k, func(t *testing.T) {
+			if got := xlsxCell(v.col, v.row); got != v.want {
+				t.Errorf("xlsxCell(%d, %d) = %q but want %q", v.col, v.row, got, v.want)
+			}
+		})
+	}
+}
+
+func TestSheetNames(t *testing.T) {
+	got := sheetNames([]xlsxSheet{
+		{name: "Summary"},
+		{name: "summary"},
+		{name: ""},
+		{name: "dhis/web:api[1]?"},
+		{name: "'quoted'"},
+		{name: "dhis-service-analytics-integration-tests"},
+		{name: "dhis-service-analytics-integration-tests-2"},
+	})
+
+	want := []string{
+		"Summary",
+		"summary (2)",
+		"no module",
+		"dhis_web_api_1__",
+		"quoted",
+		"dhis-service-analytics-integrat",
+		"dhis-service-analytics-inte (2)",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("sheetNames() mismatch (-want +got): \n%s", diff)
+	}
+}
+
+func TestXLSXNumber(t *testing.T) {
+	tc := map[string]struct {
+		header    string
+		in        string
+		want      string
+		wantStyle int
+		wantOk    bool
+	}{
+		"Seconds":         {header: "test duration [seconds]", in: "1.5", want: "1.5", wantStyle: xlsxStyleSeconds, wantOk: true},
+		"SecondsGrouped":  {header: "test duration [seconds]", in: "1,234.5", want: "1234.5", wantStyle: xlsxStyleSeconds, wantOk: true},
+		"SecondsInGerman": {header: "test duration [seconds]", in: "1,5", want: "1.5", wantStyle: xlsxStyleSeconds, wantOk: true},
+		"NegativeSeconds": {header: "suite overhead [seconds]", in: "-0.250", want: "-0.25", wantStyle: xlsxStyleSeconds, wantOk: true},
+		"Count":           {header: "test suite tests [number]", in: "1 234", want: "1234", wantStyle: xlsxStyleNumber, wantOk: true},
+		"Bytes":           {header: "test stdout [bytes]", in: "12", want: "12", wantStyle: xlsxStyleNumber, wantOk: true},
+		"NotANumber":      {header: "test duration [seconds]", in: "slow", wantOk: false},
+		"OnlyMinus":       {header: "test duration [seconds]", in: "-", wantOk: false},
+		"Text":            {header: "class", in: "12", wantOk: false},
+	}
+
+	for k, v := range tc {
+		t.Run(k, func(t *testing.T) {
+			got, style, ok := xlsxNumber(v.header, v.in)
+			if ok != v.wantOk {
+				t.Fatalf("xlsxNumber(%q, %q) ok = %t but want %t", v.header, v.in, ok, v.wantOk)
+			}
+			if got != v.want || style != v.wantStyle {
+				t.Errorf("xlsxNumber(%q, %q) = %q, %d but want %q, %d", v.header, v.in, got, style, v.want, v.wantStyle)
+			}
+		})
+	}
+}
+
+func TestConverterXLSX(t *testing.T) {
+	src := t.TempDir()
+	writeProject(t, filepath.Join(src, "dhis-web-api"), `<project>
+  <groupId>org.hisp.dhis</groupId>
+  <artifactId>dhis-web-api</artifactId>
+</project>`, "org.hisp.dhis.ApiTest")
+	writeProject(t, filepath.Join(src, "dhis-service-core"), `<project>
+  <groupId>org.hisp.dhis</groupId>
+  <artifactId>dhis-service-core</artifactId>
+</project>`, "")
+	writeReport(t, filepath.Join(src, "dhis-service-core", "target", "surefire-reports"), "TEST-org.hisp.dhis.CoreTest.xml", `<testsuite name="org.hisp.dhis.CoreTest" time="1,234.5" tests="1" errors="0" skipped="0" failures="0">
+  <testcase name="test &amp; more" classname="org.hisp.dhis.CoreTest" time="1,234.5"/>
+</testsuite>`)
+
+	var w bytes.Buffer
+	dest := t.TempDir()
+	err := Converter{From: src, Log: &w, Concat: true, Strict: true, Format: FormatXLSX, Columns: []string{"class", "test", "duration", "suite-tests"}}.To(dest)
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+
+	zr, err := zip.OpenReader(filepath.Join(dest, "surefire.xlsx"))
+	if err != nil {
+		t.Fatalf("failed to open workbook due to %s", err)
+	}
+	defer zr.Close()
+
+	workbook := readXLSXPart(t, &zr.Reader, "xl/workbook.xml")
+	for _, want := range []string{`<sheet name="Summary" sheetId="1" r:id="rId1"/>`, `<sheet name="dhis-service-core" sheetId="2" r:id="rId2"/>`, `<sheet name="dhis-web-api" sheetId="3" r:id="rId3"/>`} {
+		if !strings.Contains(workbook, want) {
+			t.Errorf("expected workbook to contain %q", want)
+		}
+	}
+
+	t.Run("Summary", func(t *testing.T) {
+		got := readXLSXSheet(t, &zr.Reader, "xl/worksheets/sheet1.xml")
+
+		want := [][]xlsxTestCell{
+			{{"A1", "1", "module"}, {"B1", "1", "tests [number]"}, {"C1", "1", "failures [number]"}, {"D1", "1", "errors [number]"}, {"E1", "1", "skipped [number]"}, {"F1", "1", "duration [seconds]"}, {"G1", "1", "suite overhead [seconds]"}, {"H1", "1", "p50 [seconds]"}, {"I1", "1", "p90 [seconds]"}, {"J1", "1", "p99 [seconds]"}},
+			{{"A2", "0", "dhis-service-core"}, {"B2", "3", "1"}, {"C2", "3", "0"}, {"D2", "3", "0"}, {"E2", "3", "0"}, {"F2", "2", "1234.5"}, {"G2", "2", "0"}, {"H2", "2", "1234.5"}, {"I2", "2", "1234.5"}, {"J2", "2", "1234.5"}},
+			{{"A3", "0", "dhis-web-api"}, {"B3", "3", "1"}, {"C3", "3", "0"}, {"D3", "3", "0"}, {"E3", "3", "0"}, {"F3", "2", "1"}, {"G3", "2", "0"}, {"H3", "2", "1"}, {"I3", "2", "1"}, {"J3", "2", "1"}},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("summary sheet mismatch (-want +got): \n%s", diff)
+		}
+	})
+
+	t.Run("Module", func(t *testing.T) {
+		got := readXLSXSheet(t, &zr.Reader, "xl/worksheets/sheet2.xml")
+
+		want := [][]xlsxTestCell{
+			{{"A1", "1", "class"}, {"B1", "1", "test"}, {"C1", "1", "test duration [seconds]"}, {"D1", "1", "test suite tests [number]"}},
+			{{"A2", "0", "org.hisp.dhis.CoreTest"}, {"B2", "0", "test & more"}, {"C2", "2", "1234.5"}, {"D2", "3", "1"}},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("module sheet mismatch (-want +got): \n%s", diff)
+		}
+
+		sheet := readXLSXPart(t, &zr.Reader, "xl/worksheets/sheet2.xml")
+		for _, want := range []string{`<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>`, `<autoFilter ref="A1:D2"/>`} {
+			if !strings.Contains(sheet, want) {
+				t.Errorf("expected sheet to contain %q", want)
+			}
+		}
+	})
+}
+
+func TestConverterXLSXNegativeOverhead(t *testing.T) {
+	src := t.TempDir()
+	writeReport(t, src, "TEST-org.hisp.dhis.CoreTest.xml", `<testsuite name="org.hisp.dhis.CoreTest" time="1" tests="1" errors="0" skipped="0" failures="0">
+  <testcase name="test" classname="org.hisp.dhis.CoreTest" time="1.25"/>
+</testsuite>`)
+
+	var w bytes.Buffer
+	dest := t.TempDir()
+	err := Converter{From: src, Log: &w, Concat: true, Strict: true, Format: FormatXLSX}.To(dest)
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+
+	zr, err := zip.OpenReader(filepath.Join(dest, "surefire.xlsx"))
+	if err != nil {
+		t.Fatalf("failed to open workbook due to %s", err)
+	}
+	defer zr.Close()
+
+	got := readXLSXSheet(t, &zr.Reader, "xl/worksheets/sheet1.xml")[1][5]
+	want := xlsxTestCell{"G2", "2", "-0.25"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("suite overhead cell mismatch (-want +got): \n%s", diff)
+	}
+}
+
+// xlsxTestCell is a cell with its reference, style and value.
+type xlsxTestCell [3]string
+
+func readXLSXPart(t *testing.T, zr *zip.Reader, name string) string {
+	t.Helper()
+	f, err := zr.Open(name)
+	if err != nil {
+		t.Fatalf("failed to open %q due to %s", name, err)
+	}
+	defer f.Close()
+	b, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("failed to read %q due to %s", name, err)
+	}
+	return string(b)
+}
+
+func readXLSXSheet(t *testing.T, zr *zip.Reader, name string) [][]xlsxTestCell {
+	t.Helper()
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Style  string `xml:"s,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal([]byte(readXLSXPart(t, zr, name)), &sheet); err != nil {
+		t.Fatalf("failed to decode %q due to %s", name, err)
+	}
+
+	var rows [][]xlsxTestCell
+	for _, r := range sheet.Rows {
+		var cells []xlsxTestCell
+		for _, c := range r.Cells {
+			cells = append(cells, xlsxTestCell{c.Ref, c.Style, c.Value + c.Inline})
+		}
+		rows = append(rows, cells)
+	}
+	return rows
+}